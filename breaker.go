@@ -0,0 +1,348 @@
+package gosolo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type BreakerConfig struct {
+	// Consecutive failures before the breaker opens
+	FailureThreshold int
+
+	// Time spent open before allowing probe requests
+	OpenTimeout time.Duration
+
+	// Concurrent probe requests allowed while half-open
+	HalfOpenProbes int
+}
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+type CircuitOpenError struct {
+	BaseURL    string
+	RetryAfter time.Time
+}
+
+var ErrCircuitOpen = fmt.Errorf("circuit open")
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+	defaultHalfOpenProbes   = 1
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+func (err *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s until %s (%s)", err.BaseURL, err.RetryAfter.Format(time.RFC3339), ErrCircuitOpen)
+}
+
+func (err *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// SetCircuitBreaker enables a circuit breaker per base URL, shared by all
+// requests and streams made through this Client. A nil cfg disables it.
+func (c *Client) SetCircuitBreaker(cfg *BreakerConfig) *Client {
+	if cfg == nil {
+		c.breakers = nil
+	} else {
+		c.breakers = newBreakerSet(cfg)
+	}
+
+	c.updateTransport()
+
+	return c
+}
+
+func (c *Client) BreakerState() BreakerState {
+	if c.breakers == nil {
+		return BreakerClosed
+	}
+
+	return c.breakers.get(c.breakerKey()).getState()
+}
+
+func (c *Client) breakerKey() string {
+	u, err := url.Parse(c.rst.BaseURL)
+	if err != nil {
+		return c.rst.BaseURL
+	}
+
+	return breakerKeyFromURL(u)
+}
+
+// waitBreaker blocks until the breaker for the current base URL would admit a
+// request, so reconnect loops share the breaker's view of the shard instead of
+// each backing off independently.
+func (c *Client) waitBreaker(ctx context.Context) {
+	if c.breakers == nil {
+		return
+	}
+
+	c.breakers.get(c.breakerKey()).wait(ctx)
+}
+
+type breakerSet struct {
+	cfg BreakerConfig
+
+	breakers map[string]*breaker
+	mu       sync.Mutex
+}
+
+func newBreakerSet(cfg *BreakerConfig) *breakerSet {
+	bs := &breakerSet{
+		cfg:      *cfg,
+		breakers: map[string]*breaker{},
+	}
+
+	if bs.cfg.FailureThreshold <= 0 {
+		bs.cfg.FailureThreshold = defaultFailureThreshold
+	}
+
+	if bs.cfg.OpenTimeout <= 0 {
+		bs.cfg.OpenTimeout = defaultOpenTimeout
+	}
+
+	if bs.cfg.HalfOpenProbes <= 0 {
+		bs.cfg.HalfOpenProbes = defaultHalfOpenProbes
+	}
+
+	return bs
+}
+
+func (bs *breakerSet) get(key string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	b := bs.breakers[key]
+	if b == nil {
+		b = newBreaker(key, &bs.cfg)
+		bs.breakers[key] = b
+	}
+
+	return b
+}
+
+func (bs *breakerSet) wrap(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		b := bs.get(breakerKeyFromURL(req.URL))
+
+		err := b.acquire()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+
+		switch {
+		case req.Context().Err() != nil:
+			// Caller gave up; says nothing about the server
+			b.release()
+
+		case err != nil:
+			b.failure()
+
+		case resp.StatusCode >= 500:
+			b.failure()
+
+		default:
+			b.success()
+		}
+
+		return resp, err
+	})
+}
+
+type breaker struct {
+	key string
+	cfg *BreakerConfig
+
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+
+	// Closed and replaced on every state change
+	changed chan struct{}
+
+	mu sync.Mutex
+}
+
+func newBreaker(key string, cfg *BreakerConfig) *breaker {
+	return &breaker{
+		key:     key,
+		cfg:     cfg,
+		changed: make(chan struct{}),
+	}
+}
+
+func (b *breaker) getState() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkTimeout()
+
+	return b.state
+}
+
+func (b *breaker) acquire() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkTimeout()
+
+	switch b.state {
+	case BreakerOpen:
+		return b.openError()
+
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return b.openError()
+		}
+
+		b.probes++
+
+	case BreakerClosed:
+	}
+
+	return nil
+}
+
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+		b.notify()
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+
+	if b.state != BreakerClosed {
+		b.setState(BreakerClosed)
+	}
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *breaker) wait(ctx context.Context) {
+	for {
+		b.mu.Lock()
+
+		b.checkTimeout()
+
+		var delay time.Duration
+
+		switch b.state {
+		case BreakerClosed:
+			b.mu.Unlock()
+			return
+
+		case BreakerHalfOpen:
+			if b.probes < b.cfg.HalfOpenProbes {
+				b.mu.Unlock()
+				return
+			}
+
+		case BreakerOpen:
+			delay = time.Until(b.openedAt.Add(b.cfg.OpenTimeout))
+		}
+
+		changed := b.changed
+
+		b.mu.Unlock()
+
+		var t *time.Timer
+
+		var timer <-chan time.Time
+
+		if delay > 0 {
+			t = time.NewTimer(delay)
+			timer = t.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timer:
+		}
+
+		if t != nil {
+			t.Stop()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// checkTimeout must be called with mu held
+func (b *breaker) checkTimeout() {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(BreakerHalfOpen)
+	}
+}
+
+// setState must be called with mu held
+func (b *breaker) setState(state BreakerState) {
+	b.state = state
+	b.probes = 0
+	b.notify()
+}
+
+// notify must be called with mu held
+func (b *breaker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// openError must be called with mu held
+func (b *breaker) openError() error {
+	return &CircuitOpenError{
+		BaseURL:    b.key,
+		RetryAfter: b.openedAt.Add(b.cfg.OpenTimeout),
+	}
+}
+
+func breakerKeyFromURL(u *url.URL) string {
+	return fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+}
@@ -0,0 +1,234 @@
+package gosolo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+const breakerTimeout = 200 * time.Millisecond
+
+// breakerServer fails with 500 while failing is set. Requests to
+// /v1/task/block wait until the client gives up.
+type breakerServer struct {
+	*httptest.Server
+
+	failing  atomic.Bool
+	requests atomic.Int32
+
+	// Receives each /v1/task/block request as it arrives
+	blocked chan struct{}
+
+	// Time of each stream request, if there's room
+	streamed chan time.Time
+}
+
+func newBreakerServer() *breakerServer {
+	srv := &breakerServer{
+		blocked:  make(chan struct{}, 10),
+		streamed: make(chan time.Time, 1),
+	}
+
+	srv.failing.Store(true)
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.requests.Add(1)
+
+		if r.Header.Get("Accept") == "text/event-stream" {
+			select {
+			case srv.streamed <- time.Now():
+			default:
+			}
+
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		if r.URL.Path == "/v1/task/block" {
+			srv.blocked <- struct{}{}
+			<-r.Context().Done()
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if srv.failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"messages":["down"]}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":"t1"}`))
+	}))
+
+	return srv
+}
+
+func newBreakerClient(srv *breakerServer) *gosolo.Client {
+	return gosolo.NewClientDirect(srv.URL).SetCircuitBreaker(&gosolo.BreakerConfig{
+		FailureThreshold: 3,
+		OpenTimeout:      breakerTimeout,
+		HalfOpenProbes:   1,
+	})
+}
+
+// openBreaker fails enough requests to open c's breaker
+func openBreaker(t *testing.T, c *gosolo.Client) {
+	t.Helper()
+
+	for i := 0; i < 3; i++ {
+		if c.BreakerState() != gosolo.BreakerClosed {
+			t.Fatalf("request %d: breaker %s before threshold", i, c.BreakerState())
+		}
+
+		_, err := c.GetTask(context.Background(), "t1", nil)
+		if err == nil || errors.Is(err, gosolo.ErrCircuitOpen) {
+			t.Fatalf("request %d: got %v, want server error", i, err)
+		}
+	}
+
+	if c.BreakerState() != gosolo.BreakerOpen {
+		t.Fatalf("breaker %s after threshold", c.BreakerState())
+	}
+}
+
+func TestBreakerOpensAndCloses(t *testing.T) {
+	t.Parallel()
+
+	srv := newBreakerServer()
+	defer srv.Close()
+
+	c := newBreakerClient(srv)
+	ctx := context.Background()
+
+	openBreaker(t, c)
+
+	_, err := c.GetTask(ctx, "t1", nil)
+
+	coErr := &gosolo.CircuitOpenError{}
+	if !errors.As(err, &coErr) || !errors.Is(err, gosolo.ErrCircuitOpen) {
+		t.Fatalf("got %v, want CircuitOpenError", err)
+	}
+
+	if coErr.BaseURL != srv.URL || time.Until(coErr.RetryAfter) > breakerTimeout {
+		t.Errorf("CircuitOpenError %+v", coErr)
+	}
+
+	if got := srv.requests.Load(); got != 3 {
+		t.Errorf("%d requests reached the server, want 3", got)
+	}
+
+	time.Sleep(breakerTimeout)
+
+	if c.BreakerState() != gosolo.BreakerHalfOpen {
+		t.Fatalf("breaker %s after OpenTimeout", c.BreakerState())
+	}
+
+	// A failed probe reopens
+	_, err = c.GetTask(ctx, "t1", nil)
+	if err == nil || errors.Is(err, gosolo.ErrCircuitOpen) {
+		t.Fatalf("probe: got %v, want server error", err)
+	}
+
+	if c.BreakerState() != gosolo.BreakerOpen {
+		t.Fatalf("breaker %s after failed probe", c.BreakerState())
+	}
+
+	time.Sleep(breakerTimeout)
+	srv.failing.Store(false)
+
+	_, err = c.GetTask(ctx, "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.BreakerState() != gosolo.BreakerClosed {
+		t.Errorf("breaker %s after good probe", c.BreakerState())
+	}
+}
+
+func TestBreakerHalfOpenProbes(t *testing.T) {
+	t.Parallel()
+
+	srv := newBreakerServer()
+	defer srv.Close()
+
+	c := newBreakerClient(srv)
+	ctx := context.Background()
+
+	openBreaker(t, c)
+	time.Sleep(breakerTimeout)
+	srv.failing.Store(false)
+
+	probeCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+
+	go func() {
+		_, err := c.GetTask(probeCtx, "block", nil)
+		done <- err
+	}()
+
+	<-srv.blocked
+
+	// The one probe slot is taken
+	_, err := c.GetTask(ctx, "t1", nil)
+	if !errors.Is(err, gosolo.ErrCircuitOpen) {
+		t.Fatalf("second probe: got %v, want ErrCircuitOpen", err)
+	}
+
+	// Cancelling the probe frees its slot without counting as a failure
+	cancel()
+	<-done
+
+	if c.BreakerState() != gosolo.BreakerHalfOpen {
+		t.Fatalf("breaker %s after cancelled probe", c.BreakerState())
+	}
+
+	_, err = c.GetTask(ctx, "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.BreakerState() != gosolo.BreakerClosed {
+		t.Errorf("breaker %s after good probe", c.BreakerState())
+	}
+}
+
+func TestBreakerGatesStreams(t *testing.T) {
+	t.Parallel()
+
+	srv := newBreakerServer()
+	defer srv.Close()
+
+	c := newBreakerClient(srv)
+
+	openBreaker(t, c)
+	openedAt := time.Now()
+
+	stream, err := c.StreamListToken(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer stream.Close()
+
+	// The stream's first connect waits for the breaker to half-open
+	select {
+	case at := <-srv.streamed:
+		if at.Before(openedAt.Add(breakerTimeout - 20*time.Millisecond)) {
+			t.Errorf("stream connected %s after the breaker opened, before OpenTimeout", at.Sub(openedAt))
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream never connected")
+	}
+}
@@ -0,0 +1,274 @@
+// Package bulk exports and imports any resource type as CSV or JSON Lines.
+//
+// CSV columns are the object's JSON field names, in struct order, with
+// embedded structs (including metadata) flattened the way encoding/json
+// does. String-like values (including times) are written bare; everything
+// else as its JSON encoding. Empty cells leave the field at its zero value.
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+type Format int
+
+const (
+	CSV Format = iota
+	JSONLines
+)
+
+var ErrInvalidRecord = fmt.Errorf("invalid record")
+
+type column struct {
+	name  string
+	index []int
+
+	// JSON encoding of the field type is a string, so cells are quoted
+	// back into strings on import
+	quoted bool
+}
+
+// Columns returns the CSV header for T.
+func Columns[T any]() []string {
+	ret := []string{}
+
+	for _, col := range columns(reflect.TypeOf(new(T)).Elem()) {
+		ret = append(ret, col.name)
+	}
+
+	return ret
+}
+
+func columns(t reflect.Type) []*column {
+	ret := []*column{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, sub := range columns(field.Type) {
+				sub.index = append([]int{i}, sub.index...)
+				ret = append(ret, sub)
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		zero, _ := json.Marshal(reflect.Zero(field.Type).Interface())
+
+		ret = append(ret, &column{
+			name:   name,
+			index:  []int{i},
+			quoted: len(zero) > 0 && zero[0] == '"',
+		})
+	}
+
+	return ret
+}
+
+type encoder[T any] interface {
+	encode(*T) error
+	flush() error
+}
+
+type decoder[T any] interface {
+	// decode returns io.EOF after the last record
+	decode() (*T, error)
+}
+
+func newEncoder[T any](w io.Writer, format Format) (encoder[T], error) {
+	switch format {
+	case CSV:
+		enc := &csvEncoder[T]{
+			w:    csv.NewWriter(w),
+			cols: columns(reflect.TypeOf(new(T)).Elem()),
+		}
+
+		err := enc.w.Write(Columns[T]())
+		if err != nil {
+			return nil, err
+		}
+
+		return enc, nil
+
+	case JSONLines:
+		return &jsonlEncoder[T]{
+			enc: json.NewEncoder(w),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+}
+
+func newDecoder[T any](r io.Reader, format Format) (decoder[T], error) {
+	switch format {
+	case CSV:
+		dec := &csvDecoder[T]{
+			r:    csv.NewReader(r),
+			cols: map[string]*column{},
+		}
+
+		for _, col := range columns(reflect.TypeOf(new(T)).Elem()) {
+			dec.cols[col.name] = col
+		}
+
+		header, err := dec.r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("read CSV header failed (%w)", err)
+		}
+
+		for _, name := range header {
+			col := dec.cols[name]
+			if col == nil {
+				return nil, fmt.Errorf("unknown column %s (%w)", name, ErrInvalidRecord)
+			}
+
+			dec.header = append(dec.header, col)
+		}
+
+		return dec, nil
+
+	case JSONLines:
+		return &jsonlDecoder[T]{
+			dec: json.NewDecoder(r),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown format %d", format)
+	}
+}
+
+type csvEncoder[T any] struct {
+	w    *csv.Writer
+	cols []*column
+}
+
+func (enc *csvEncoder[T]) encode(obj *T) error {
+	v := reflect.ValueOf(obj).Elem()
+	row := []string{}
+
+	for _, col := range enc.cols {
+		field := v.FieldByIndex(col.index)
+
+		if field.IsZero() {
+			row = append(row, "")
+			continue
+		}
+
+		js, err := json.Marshal(field.Interface())
+		if err != nil {
+			return err
+		}
+
+		if col.quoted {
+			var s string
+
+			err = json.Unmarshal(js, &s)
+			if err != nil {
+				return err
+			}
+
+			row = append(row, s)
+		} else {
+			row = append(row, string(js))
+		}
+	}
+
+	return enc.w.Write(row)
+}
+
+func (enc *csvEncoder[T]) flush() error {
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+type csvDecoder[T any] struct {
+	r      *csv.Reader
+	cols   map[string]*column
+	header []*column
+}
+
+func (dec *csvDecoder[T]) decode() (*T, error) {
+	row, err := dec.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := new(T)
+	v := reflect.ValueOf(obj).Elem()
+
+	for i, cell := range row {
+		if cell == "" {
+			continue
+		}
+
+		col := dec.header[i]
+
+		js := []byte(cell)
+		if col.quoted {
+			js, _ = json.Marshal(cell)
+		}
+
+		// One JSON value per cell, decoded into just that field, so a cell
+		// can't set others
+		err = json.Unmarshal(js, v.FieldByIndex(col.index).Addr().Interface())
+		if err != nil {
+			line, _ := dec.r.FieldPos(i)
+			return nil, fmt.Errorf("line %d: %s: %s (%w)", line, col.name, err, ErrInvalidRecord) //nolint:errorlint
+		}
+	}
+
+	return obj, nil
+}
+
+type jsonlEncoder[T any] struct {
+	enc *json.Encoder
+}
+
+func (enc *jsonlEncoder[T]) encode(obj *T) error {
+	return enc.enc.Encode(obj)
+}
+
+func (enc *jsonlEncoder[T]) flush() error {
+	return nil
+}
+
+type jsonlDecoder[T any] struct {
+	dec *json.Decoder
+}
+
+func (dec *jsonlDecoder[T]) decode() (*T, error) {
+	obj := new(T)
+
+	err := dec.dec.Decode(obj)
+	if err == io.EOF { //nolint:errorlint
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s (%w)", err, ErrInvalidRecord) //nolint:errorlint
+	}
+
+	return obj, nil
+}
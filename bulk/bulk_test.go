@@ -0,0 +1,73 @@
+package bulk_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/bulk"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("user", map[string]any{"id": "u1", "name": "Doe, Jane", "email": "jane@example.com", "serviceAdmin": true})
+	srv.Put("user", map[string]any{"id": "u2", "name": `Say "hi"`, "email": "bob@example.com"})
+
+	ctx := context.Background()
+	c := gosolo.NewClientDirect(srv.URL)
+	buf := &bytes.Buffer{}
+
+	n, err := bulk.Export[gosolo.User](ctx, c, "user", buf, bulk.CSV, &bulk.ExportOpts{PageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Fatalf("exported %d", n)
+	}
+
+	res, err := bulk.Import[gosolo.User](ctx, c, "user", buf, bulk.CSV, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Updated != 2 || res.Created != 0 {
+		t.Errorf("import: %+v", res)
+	}
+
+	if obj := srv.Get("user", "u1"); obj["name"] != "Doe, Jane" || obj["serviceAdmin"] != true {
+		t.Errorf("u1 after round trip: %v", obj)
+	}
+}
+
+func TestCSVCellCannotSetOtherFields(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	c := gosolo.NewClientDirect(srv.URL)
+
+	for _, in := range []string{
+		"name,replicationClient\nmallory,\"true,\"\"serviceAdmin\"\":true\"\n",
+		"name,replicationClient\nmallory,true}\n",
+		"name,replicationClient\nmallory,\"\"\"yes\"\"\"\n",
+	} {
+		_, err := bulk.Import[gosolo.User](context.Background(), c, "user", strings.NewReader(in), bulk.CSV, nil)
+		if !errors.Is(err, bulk.ErrInvalidRecord) {
+			t.Errorf("%q: got %v, want ErrInvalidRecord", in, err)
+		}
+	}
+
+	if n := len(srv.List("user")); n != 0 {
+		t.Errorf("%d users created", n)
+	}
+}
@@ -0,0 +1,201 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/gopatchy/metadata"
+	"github.com/tasksolo/gosolo"
+)
+
+type ExportOpts struct {
+	Filters []gosolo.Filter
+
+	// Objects per request; defaults to 100
+	PageSize int64
+}
+
+type ImportOpts struct {
+	// Parse every record and look up existing objects, but write nothing
+	DryRun bool
+
+	// Requests in flight at once; defaults to 8
+	Concurrency int
+}
+
+type ImportResult struct {
+	Created int
+	Updated int
+}
+
+const (
+	defaultPageSize    = 100
+	defaultConcurrency = 8
+)
+
+// Export writes every name object to w one page at a time, returning the
+// number written. T must match name, e.g. Export[gosolo.User](ctx, c,
+// "user", ...).
+func Export[T any](ctx context.Context, c *gosolo.Client, name string, w io.Writer, format Format, opts *ExportOpts) (int, error) {
+	if opts == nil {
+		opts = &ExportOpts{}
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	enc, err := newEncoder[T](w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	after := ""
+
+	for {
+		page, err := gosolo.ListName[T](ctx, c, name, &gosolo.ListOpts[T]{
+			Limit:   pageSize,
+			After:   after,
+			Sorts:   []string{"+id"},
+			Filters: opts.Filters,
+		})
+		if err != nil {
+			return count, err
+		}
+
+		for _, obj := range page {
+			err = enc.encode(obj)
+			if err != nil {
+				return count, err
+			}
+
+			count++
+		}
+
+		if int64(len(page)) < pageSize {
+			break
+		}
+
+		after = metadata.GetMetadata(page[len(page)-1]).ID
+	}
+
+	return count, enc.flush()
+}
+
+// Import reads objects from r and writes them to the server, up to
+// Concurrency at a time. Records whose id exists replace that object; all
+// others are created with server-assigned metadata.
+func Import[T any](ctx context.Context, c *gosolo.Client, name string, r io.Reader, format Format, opts *ImportOpts) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOpts{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	dec, err := newDecoder[T](r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ret := &ImportResult{}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+
+	var firstErr error
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	for ctx.Err() == nil {
+		obj, err := dec.decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			fail(err)
+			break
+		}
+
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			created, err := importOne(ctx, c, name, obj, opts.DryRun)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if created {
+				ret.Created++
+			} else {
+				ret.Updated++
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return ret, firstErr
+	}
+
+	return ret, ctx.Err()
+}
+
+func importOne[T any](ctx context.Context, c *gosolo.Client, name string, obj *T, dryRun bool) (bool, error) {
+	id := metadata.GetMetadata(obj).ID
+	metadata.ClearMetadata(obj)
+
+	var existing *T
+
+	if id != "" {
+		var err error
+
+		existing, err = gosolo.GetName[T](ctx, c, name, id, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if dryRun {
+		return existing == nil, nil
+	}
+
+	if existing == nil {
+		_, err := gosolo.CreateName[T](ctx, c, name, obj)
+		return true, err
+	}
+
+	_, err := gosolo.ReplaceName[T](ctx, c, name, id, obj, nil)
+
+	return false, err
+}
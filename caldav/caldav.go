@@ -0,0 +1,578 @@
+// Package caldav serves a user's tasks to CalDAV (RFC 4791) clients as VTODO
+// resources, backed by a gosolo Client.
+//
+// The server exposes a single calendar collection at /tasks/ with one
+// resource per task. GET, PUT and DELETE map to GetTask, CreateTask or
+// UpdateTask, and DeleteTask; REPORT supports calendar-query (filters are
+// left to the client) and calendar-multiget. ETags are the task metadata
+// ETags, and If-Match / If-None-Match are honored.
+//
+// Resource names and UIDs chosen by clients on create are remembered in
+// memory only; after a restart those tasks appear under their task IDs.
+//
+// Every request acts with the Client's credentials, so set SetBasicAuth
+// before exposing the server beyond loopback.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopatchy/jsrest"
+	"github.com/gopatchy/metadata"
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/ical"
+)
+
+const (
+	collectionPath = "/tasks/"
+	icsSuffix      = ".ics"
+
+	nsDAV     = "DAV:"
+	nsCalDAV  = "urn:ietf:params:xml:ns:caldav"
+	nsCalSrv  = "http://calendarserver.org/ns/"
+	icsType   = "text/calendar; charset=utf-8"
+	xmlType   = "application/xml; charset=utf-8"
+	maxBodyKB = 1024
+)
+
+type Server struct {
+	client *gosolo.Client
+
+	// Required Basic auth, if user is set
+	user string
+	pass string
+
+	// Clients pick resource names and UIDs on create, but the task gets a
+	// server ID; remember what the client used so it sees them again
+	aliases map[string]string // name -> task ID
+	aliasOf map[string]*alias // task ID -> alias
+	mu      sync.Mutex
+}
+
+type alias struct {
+	name string
+	uid  string
+}
+
+var ErrAuthRequired = fmt.Errorf("basic auth required to listen beyond loopback")
+
+func NewServer(c *gosolo.Client) *Server {
+	return &Server{
+		client:  c,
+		aliases: map[string]string{},
+		aliasOf: map[string]*alias{},
+	}
+}
+
+// SetBasicAuth makes the server reject requests without these credentials.
+func (s *Server) SetBasicAuth(user, pass string) *Server {
+	s.user = user
+	s.pass = pass
+
+	return s
+}
+
+// ListenAndServe runs s on addr until ctx is done. Without SetBasicAuth,
+// addr must be a loopback address.
+func ListenAndServe(ctx context.Context, addr string, s *Server) error {
+	if s.user == "" && !isLoopback(addr) {
+		return fmt.Errorf("%s (%w)", addr, ErrAuthRequired)
+	}
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="tasks", charset="UTF-8"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+		return
+	}
+
+	var err error
+
+	switch r.Method {
+	case http.MethodOptions:
+		s.serveOptions(w)
+
+	case "PROPFIND":
+		err = s.servePropfind(w, r)
+
+	case "REPORT":
+		err = s.serveReport(w, r)
+
+	case http.MethodGet, http.MethodHead:
+		err = s.serveGet(w, r)
+
+	case http.MethodPut:
+		err = s.servePut(w, r)
+
+	case http.MethodDelete:
+		err = s.serveDelete(w, r)
+
+	default:
+		err = jsrest.Errorf(jsrest.ErrMethodNotAllowed, "%s", r.Method)
+	}
+
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.user == "" {
+		return true
+	}
+
+	user, pass, ok := r.BasicAuth()
+
+	// Compare both, so timing doesn't reveal which was wrong
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(s.user)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(s.pass)) == 1
+
+	return ok && userOK && passOK
+}
+
+func (s *Server) serveOptions(w http.ResponseWriter) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	w.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT, GET, HEAD, PUT, DELETE")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) servePropfind(w http.ResponseWriter, r *http.Request) error {
+	req, err := readPropfind(r)
+	if err != nil {
+		return err
+	}
+
+	depth := r.Header.Get("Depth")
+	ms := newMultistatus()
+
+	switch {
+	case r.URL.Path == "/" || r.URL.Path == "":
+		ms.add("/", rootProps(), req)
+
+		if depth == "1" {
+			props, err := s.collectionProps(r.Context())
+			if err != nil {
+				return err
+			}
+
+			ms.add(collectionPath, props, req)
+		}
+
+	case r.URL.Path == collectionPath || r.URL.Path+"/" == collectionPath:
+		props, err := s.collectionProps(r.Context())
+		if err != nil {
+			return err
+		}
+
+		ms.add(collectionPath, props, req)
+
+		if depth == "1" {
+			list, err := s.client.ListTask(r.Context(), nil)
+			if err != nil {
+				return err
+			}
+
+			for _, task := range list {
+				ms.add(s.href(task.ID), s.taskProps(task, false), req)
+			}
+		}
+
+	default:
+		task, err := s.getTask(r.Context(), r.URL.Path)
+		if err != nil {
+			return err
+		}
+
+		ms.add(r.URL.Path, s.taskProps(task, false), req)
+	}
+
+	return ms.write(w)
+}
+
+func (s *Server) serveReport(w http.ResponseWriter, r *http.Request) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyKB*1024))
+	if err != nil {
+		return err
+	}
+
+	report := &reportRequest{}
+
+	err = xml.Unmarshal(body, report)
+	if err != nil {
+		return jsrest.Errorf(jsrest.ErrBadRequest, "parse REPORT body failed (%w)", err)
+	}
+
+	req := &propfindRequest{props: report.Prop.names()}
+	ms := newMultistatus()
+
+	switch report.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		list, err := s.client.ListTask(r.Context(), nil)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range list {
+			ms.add(s.href(task.ID), s.taskProps(task, true), req)
+		}
+
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		for _, href := range report.Hrefs {
+			task, err := s.getTask(r.Context(), href)
+			if err != nil {
+				ms.addStatus(href, http.StatusNotFound)
+				continue
+			}
+
+			ms.add(href, s.taskProps(task, true), req)
+		}
+
+	default:
+		return jsrest.Errorf(jsrest.ErrForbidden, "unsupported report %s", report.XMLName.Local)
+	}
+
+	return ms.write(w)
+}
+
+func (s *Server) serveGet(w http.ResponseWriter, r *http.Request) error {
+	task, err := s.getTask(r.Context(), r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.encode(task)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", icsType)
+	w.Header().Set("ETag", quote(task.ETag))
+
+	if r.Method == http.MethodHead {
+		w.WriteHeader(http.StatusOK)
+		return nil
+	}
+
+	_, err = io.WriteString(w, data)
+
+	return err
+}
+
+func (s *Server) servePut(w http.ResponseWriter, r *http.Request) error {
+	name, ok := resourceName(r.URL.Path)
+	if !ok {
+		return jsrest.Errorf(jsrest.ErrMethodNotAllowed, "PUT outside %s", collectionPath)
+	}
+
+	list, err := ical.ReadCalendar(io.LimitReader(r.Body, maxBodyKB*1024))
+	if err != nil {
+		return jsrest.Errorf(jsrest.ErrBadRequest, "%w", err)
+	}
+
+	if len(list) != 1 {
+		return jsrest.Errorf(jsrest.ErrBadRequest, "expected exactly one VTODO, got %d", len(list))
+	}
+
+	body := *list[0]
+	body.Metadata = metadata.Metadata{}
+
+	existing, err := s.client.GetTask(r.Context(), s.taskID(name), nil)
+	if err != nil {
+		return err
+	}
+
+	err = checkPreconditions(r, existing)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		created, err := s.client.CreateTask(r.Context(), &body)
+		if err != nil {
+			return err
+		}
+
+		s.setAlias(created.ID, name, list[0].ID)
+
+		w.Header().Set("ETag", quote(created.ETag))
+		w.WriteHeader(http.StatusCreated)
+
+		return nil
+	}
+
+	// PUT replaces, and PATCH can't clear fields, so start from a full object
+	replacement := *existing
+	replacement.Name = body.Name
+	replacement.After = body.After
+	replacement.Complete = body.Complete
+	replacement.Metadata = metadata.Metadata{}
+
+	updated, err := s.client.ReplaceTask(r.Context(), existing.ID, &replacement, &gosolo.UpdateOpts[gosolo.Task]{Prev: existing})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("ETag", quote(updated.ETag))
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) serveDelete(w http.ResponseWriter, r *http.Request) error {
+	task, err := s.getTask(r.Context(), r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	err = checkPreconditions(r, task)
+	if err != nil {
+		return err
+	}
+
+	err = s.client.DeleteTask(r.Context(), task.ID, &gosolo.UpdateOpts[gosolo.Task]{Prev: task})
+	if err != nil {
+		return err
+	}
+
+	s.removeAlias(task.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func (s *Server) collectionProps(ctx context.Context) (map[xml.Name]string, error) {
+	list, err := s.client.ListTask(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctag := ""
+	if len(list) > 0 {
+		ctag = list[0].ListETag
+	}
+
+	return map[xml.Name]string{
+		{Space: nsDAV, Local: "resourcetype"}:                        "<d:collection/><c:calendar/>",
+		{Space: nsDAV, Local: "displayname"}:                         "Solø tasks",
+		{Space: nsCalDAV, Local: "supported-calendar-component-set"}: `<c:comp name="VTODO"/>`,
+		{Space: nsCalSrv, Local: "getctag"}:                          escapeText(ctag),
+		{Space: nsDAV, Local: "current-user-principal"}:              "<d:href>/</d:href>",
+	}, nil
+}
+
+func rootProps() map[xml.Name]string {
+	return map[xml.Name]string{
+		{Space: nsDAV, Local: "resourcetype"}:                 "<d:collection/>",
+		{Space: nsDAV, Local: "current-user-principal"}:       "<d:href>/</d:href>",
+		{Space: nsCalDAV, Local: "calendar-home-set"}:         "<d:href>/</d:href>",
+		{Space: nsCalDAV, Local: "calendar-user-address-set"}: "",
+	}
+}
+
+func (s *Server) taskProps(task *gosolo.Task, withData bool) map[xml.Name]string {
+	props := map[xml.Name]string{
+		{Space: nsDAV, Local: "resourcetype"}:   "",
+		{Space: nsDAV, Local: "getetag"}:        escapeText(quote(task.ETag)),
+		{Space: nsDAV, Local: "getcontenttype"}: icsType,
+	}
+
+	if withData {
+		data, err := s.encode(task)
+		if err == nil {
+			props[xml.Name{Space: nsCalDAV, Local: "calendar-data"}] = escapeText(data)
+		}
+	}
+
+	return props
+}
+
+func (s *Server) getTask(ctx context.Context, p string) (*gosolo.Task, error) {
+	name, ok := resourceName(p)
+	if !ok {
+		return nil, jsrest.Errorf(jsrest.ErrNotFound, "%s", p)
+	}
+
+	task, err := s.client.GetTask(ctx, s.taskID(name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if task == nil {
+		return nil, jsrest.Errorf(jsrest.ErrNotFound, "%s", p)
+	}
+
+	return task, nil
+}
+
+func (s *Server) encode(task *gosolo.Task) (string, error) {
+	s.mu.Lock()
+	a := s.aliasOf[task.ID]
+	s.mu.Unlock()
+
+	if a != nil && a.uid != "" {
+		tmp := *task
+		tmp.ID = a.uid
+		task = &tmp
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := ical.WriteCalendar(buf, []*gosolo.Task{task})
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (s *Server) href(id string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := id
+	if a := s.aliasOf[id]; a != nil {
+		name = a.name
+	}
+
+	return collectionPath + name + icsSuffix
+}
+
+func (s *Server) taskID(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id := s.aliases[name]; id != "" {
+		return id
+	}
+
+	return name
+}
+
+func (s *Server) setAlias(id, name, uid string) {
+	if name == id && (uid == "" || uid == id) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.aliases[name] = id
+	s.aliasOf[id] = &alias{
+		name: name,
+		uid:  uid,
+	}
+}
+
+func (s *Server) removeAlias(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a := s.aliasOf[id]; a != nil {
+		delete(s.aliases, a.name)
+	}
+
+	delete(s.aliasOf, id)
+}
+
+func checkPreconditions(r *http.Request, task *gosolo.Task) error {
+	if match := r.Header.Get("If-Match"); match != "" {
+		if task == nil || (match != "*" && match != quote(task.ETag)) {
+			return jsrest.Errorf(jsrest.ErrPreconditionFailed, "If-Match: %s", match)
+		}
+	}
+
+	if noneMatch := r.Header.Get("If-None-Match"); noneMatch != "" && task != nil {
+		if noneMatch == "*" || noneMatch == quote(task.ETag) {
+			return jsrest.Errorf(jsrest.ErrPreconditionFailed, "If-None-Match: %s", noneMatch)
+		}
+	}
+
+	return nil
+}
+
+func resourceName(p string) (string, bool) {
+	dir, file := path.Split(p)
+	if dir != collectionPath || !strings.HasSuffix(file, icsSuffix) {
+		return "", false
+	}
+
+	name := strings.TrimSuffix(file, icsSuffix)
+
+	return name, name != ""
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+
+	if hErr := jsrest.GetHTTPError(err); hErr != nil {
+		code = hErr.Code
+	}
+
+	// Server errors arrive as message lists; the status is in the "[412] ..."
+	// message from the server's HTTPError
+	je := &jsrest.JSONError{}
+	if errors.As(err, &je) {
+		for _, msg := range je.Messages {
+			var status int
+
+			_, scanErr := fmt.Sscanf(msg, "[%d]", &status)
+			if scanErr == nil && status >= 400 && status < 600 {
+				code = status
+			}
+		}
+	}
+
+	http.Error(w, err.Error(), code)
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+func quote(etag string) string {
+	return fmt.Sprintf(`"%s"`, etag)
+}
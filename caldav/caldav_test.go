@@ -0,0 +1,84 @@
+package caldav_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/caldav"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+)
+
+func TestBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	api := fakeapi.New()
+	defer api.Close()
+
+	api.Put("task", map[string]any{"id": "abc", "name": "water plants"})
+
+	srv := httptest.NewServer(caldav.NewServer(gosolo.NewClientDirect(api.URL)).SetBasicAuth("me", "secret"))
+	defer srv.Close()
+
+	tests := []struct {
+		user string
+		pass string
+		want int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"me", "wrong", http.StatusUnauthorized},
+		{"you", "secret", http.StatusUnauthorized},
+		{"me", "secret", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/tasks/abc.ics", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.user != "" {
+			req.SetBasicAuth(test.user, test.pass)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != test.want {
+			t.Errorf("%s:%s got %d, want %d", test.user, test.pass, resp.StatusCode, test.want)
+		}
+	}
+
+	if n := api.Count(http.MethodGet, "task"); n != 1 {
+		t.Errorf("%d API requests, want 1 (only the authorized one)", n)
+	}
+}
+
+func TestListenRequiresAuthBeyondLoopback(t *testing.T) {
+	t.Parallel()
+
+	c := gosolo.NewClientDirect("http://api.invalid")
+
+	for _, addr := range []string{":0", "0.0.0.0:0", "[::]:0"} {
+		err := caldav.ListenAndServe(context.Background(), addr, caldav.NewServer(c))
+		if !errors.Is(err, caldav.ErrAuthRequired) {
+			t.Errorf("%s: got %v, want ErrAuthRequired", addr, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := caldav.ListenAndServe(ctx, "127.0.0.1:0", caldav.NewServer(c))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("loopback: got %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,187 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gopatchy/jsrest"
+)
+
+var prefixes = map[string]string{
+	nsDAV:    "d",
+	nsCalDAV: "c",
+	nsCalSrv: "cs",
+}
+
+type propfindRequest struct {
+	// nil means allprop
+	props []xml.Name
+}
+
+type propfindBody struct {
+	XMLName xml.Name  `xml:"DAV: propfind"`
+	Prop    *propList `xml:"DAV: prop"`
+}
+
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    *propList `xml:"DAV: prop"`
+	Hrefs   []string  `xml:"DAV: href"`
+}
+
+type propList struct {
+	Any []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+func (pl *propList) names() []xml.Name {
+	if pl == nil {
+		return nil
+	}
+
+	ret := []xml.Name{}
+
+	for _, p := range pl.Any {
+		ret = append(ret, p.XMLName)
+	}
+
+	return ret
+}
+
+func readPropfind(r *http.Request) (*propfindRequest, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyKB*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return &propfindRequest{}, nil
+	}
+
+	pf := &propfindBody{}
+
+	err = xml.Unmarshal(body, pf)
+	if err != nil {
+		return nil, jsrest.Errorf(jsrest.ErrBadRequest, "parse PROPFIND body failed (%w)", err)
+	}
+
+	return &propfindRequest{props: pf.Prop.names()}, nil
+}
+
+type multistatus struct {
+	buf bytes.Buffer
+}
+
+func newMultistatus() *multistatus {
+	ms := &multistatus{}
+	ms.buf.WriteString(xml.Header)
+	ms.buf.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+
+	return ms
+}
+
+// add writes a response for href with the requested props that exist in
+// props, and a 404 propstat for those that don't.
+func (ms *multistatus) add(href string, props map[xml.Name]string, req *propfindRequest) {
+	found := []xml.Name{}
+	missing := []xml.Name{}
+
+	if req.props == nil {
+		for name := range props {
+			found = append(found, name)
+		}
+
+		sort.Slice(found, func(i, j int) bool { return found[i].Local < found[j].Local })
+	} else {
+		for _, name := range req.props {
+			if _, ok := props[name]; ok {
+				found = append(found, name)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+	}
+
+	ms.buf.WriteString("<d:response><d:href>")
+	ms.buf.WriteString(escapeText(href))
+	ms.buf.WriteString("</d:href>")
+
+	if len(found) > 0 {
+		ms.buf.WriteString("<d:propstat><d:prop>")
+
+		for _, name := range found {
+			ms.writeProp(name, props[name])
+		}
+
+		ms.buf.WriteString("</d:prop>")
+		ms.writeStatus(http.StatusOK)
+		ms.buf.WriteString("</d:propstat>")
+	}
+
+	if len(missing) > 0 {
+		ms.buf.WriteString("<d:propstat><d:prop>")
+
+		for _, name := range missing {
+			ms.writeProp(name, "")
+		}
+
+		ms.buf.WriteString("</d:prop>")
+		ms.writeStatus(http.StatusNotFound)
+		ms.buf.WriteString("</d:propstat>")
+	}
+
+	ms.buf.WriteString("</d:response>")
+}
+
+func (ms *multistatus) addStatus(href string, code int) {
+	ms.buf.WriteString("<d:response><d:href>")
+	ms.buf.WriteString(escapeText(href))
+	ms.buf.WriteString("</d:href>")
+	ms.writeStatus(code)
+	ms.buf.WriteString("</d:response>")
+}
+
+func (ms *multistatus) writeProp(name xml.Name, inner string) {
+	prefix := prefixes[name.Space]
+
+	if prefix == "" {
+		// Unknown namespace, declare it inline
+		fmt.Fprintf(&ms.buf, `<x:%s xmlns:x="%s">%s</x:%s>`, name.Local, escapeText(name.Space), inner, name.Local)
+		return
+	}
+
+	if inner == "" {
+		fmt.Fprintf(&ms.buf, "<%s:%s/>", prefix, name.Local)
+		return
+	}
+
+	fmt.Fprintf(&ms.buf, "<%s:%s>%s</%s:%s>", prefix, name.Local, inner, prefix, name.Local)
+}
+
+func (ms *multistatus) writeStatus(code int) {
+	fmt.Fprintf(&ms.buf, "<d:status>HTTP/1.1 %d %s</d:status>", code, http.StatusText(code))
+}
+
+func (ms *multistatus) write(w http.ResponseWriter) error {
+	ms.buf.WriteString("</d:multistatus>")
+
+	w.Header().Set("Content-Type", xmlType)
+	w.WriteHeader(http.StatusMultiStatus)
+
+	_, err := w.Write(ms.buf.Bytes())
+
+	return err
+}
+
+func escapeText(s string) string {
+	b := strings.Builder{}
+	_ = xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
@@ -0,0 +1,472 @@
+package gosolo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type CassetteMode int
+
+const (
+	CassetteRecord CassetteMode = iota
+	CassetteReplay
+)
+
+// Cassette records request/response pairs (including stream bodies and their
+// timing) to a file, or replays them in place of the network. Credentials are
+// redacted before anything is written.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	// Sleep between stream chunks as recorded during replay
+	ReplayDelays bool `json:"-"`
+
+	path string
+	mode CassetteMode
+	used []bool
+
+	mu sync.Mutex
+}
+
+type Interaction struct {
+	Request  *CassetteRequest  `json:"request"`
+	Response *CassetteResponse `json:"response"`
+}
+
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	StatusCode int              `json:"statusCode"`
+	Header     http.Header      `json:"header,omitempty"`
+	Body       string           `json:"body,omitempty"`
+	Chunks     []*CassetteChunk `json:"chunks,omitempty"`
+
+	// Server ended the stream, rather than the client closing it
+	EOF bool `json:"eof,omitempty"`
+}
+
+type CassetteChunk struct {
+	Delay time.Duration `json:"delay"`
+	Data  string        `json:"data"`
+}
+
+var ErrCassetteMiss = fmt.Errorf("no matching cassette interaction")
+
+// OpenCassette starts an empty cassette that Save writes to path in record
+// mode, or loads path in replay mode.
+func OpenCassette(path string, mode CassetteMode) (*Cassette, error) {
+	cas := &Cassette{
+		Interactions: []*Interaction{},
+		path:         path,
+		mode:         mode,
+	}
+
+	if mode == CassetteRecord {
+		return cas, nil
+	}
+
+	js, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(js, cas)
+	if err != nil {
+		return nil, err
+	}
+
+	cas.used = make([]bool, len(cas.Interactions))
+
+	return cas, nil
+}
+
+// SetCassette routes all requests through cas instead of (replay) or in
+// addition to (record) the network. A nil cas restores normal operation.
+func (c *Client) SetCassette(cas *Cassette) *Client {
+	c.cassette = cas
+	c.updateTransport()
+
+	return c
+}
+
+func (cas *Cassette) Save() error {
+	cas.mu.Lock()
+	js, err := json.MarshalIndent(cas, "", "\t")
+	cas.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(cas.path, js, 0o600)
+}
+
+func (cas *Cassette) wrap(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		creq, err := newCassetteRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if cas.mode == CassetteReplay {
+			return cas.replay(req, creq)
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		return cas.record(creq, resp)
+	})
+}
+
+func (cas *Cassette) record(creq *CassetteRequest, resp *http.Response) (*http.Response, error) {
+	cresp := &CassetteResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	}
+
+	redactHeaders(cresp.Header)
+
+	cas.mu.Lock()
+	cas.Interactions = append(cas.Interactions, &Interaction{
+		Request:  creq,
+		Response: cresp,
+	})
+	cas.mu.Unlock()
+
+	if isEventStream(resp.Header) {
+		resp.Body = &recordingBody{
+			ReadCloser: resp.Body,
+			cas:        cas,
+			cresp:      cresp,
+			last:       time.Now(),
+		}
+
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cas.mu.Lock()
+	cresp.Body = redactBody(string(body))
+	cas.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return resp, nil
+}
+
+func (cas *Cassette) replay(req *http.Request, creq *CassetteRequest) (*http.Response, error) {
+	cas.mu.Lock()
+	defer cas.mu.Unlock()
+
+	for i, inter := range cas.Interactions {
+		if cas.used[i] || !inter.Request.matches(creq) {
+			continue
+		}
+
+		cas.used[i] = true
+
+		resp := &http.Response{
+			Status:     fmt.Sprintf("%d %s", inter.Response.StatusCode, http.StatusText(inter.Response.StatusCode)),
+			StatusCode: inter.Response.StatusCode,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     inter.Response.Header.Clone(),
+			Request:    req,
+		}
+
+		if resp.Header == nil {
+			resp.Header = http.Header{}
+		}
+
+		if isEventStream(inter.Response.Header) {
+			body := newReplayBody(inter.Response, cas.ReplayDelays)
+			go body.closeOnDone(req.Context())
+			resp.Body = body
+		} else {
+			resp.Body = io.NopCloser(strings.NewReader(inter.Response.Body))
+		}
+
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("%s %s (%w)", creq.Method, creq.URL, ErrCassetteMiss)
+}
+
+func newCassetteRequest(req *http.Request) (*CassetteRequest, error) {
+	creq := &CassetteRequest{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Header: req.Header.Clone(),
+	}
+
+	redactHeaders(creq.Header)
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return creq, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	creq.Body = redactBody(string(body))
+
+	return creq, nil
+}
+
+// Host is ignored so cassettes replay against any base URL
+func (creq *CassetteRequest) matches(other *CassetteRequest) bool {
+	return creq.Method == other.Method &&
+		creq.URL == other.URL &&
+		creq.Body == other.Body
+}
+
+func isEventStream(hdr http.Header) bool {
+	return strings.HasPrefix(hdr.Get("Content-Type"), "text/event-stream")
+}
+
+type recordingBody struct {
+	io.ReadCloser
+
+	cas   *Cassette
+	cresp *CassetteResponse
+	last  time.Time
+
+	// Incomplete event, held until it can be redacted whole
+	pending []byte
+}
+
+func (rb *recordingBody) Read(p []byte) (int, error) {
+	n, err := rb.ReadCloser.Read(p)
+
+	rb.pending = append(rb.pending, p[:n]...)
+
+	end := lastEventEnd(rb.pending)
+
+	if errors.Is(err, io.EOF) {
+		end = len(rb.pending)
+	}
+
+	rb.store(end, errors.Is(err, io.EOF))
+
+	return n, err
+}
+
+func (rb *recordingBody) Close() error {
+	rb.store(len(rb.pending), false)
+	return rb.ReadCloser.Close()
+}
+
+// store records pending[:end] as one chunk
+func (rb *recordingBody) store(end int, eof bool) {
+	now := time.Now()
+
+	rb.cas.mu.Lock()
+
+	if end > 0 {
+		rb.cresp.Chunks = append(rb.cresp.Chunks, &CassetteChunk{
+			Delay: now.Sub(rb.last),
+			Data:  redactEvents(string(rb.pending[:end])),
+		})
+
+		rb.last = now
+	}
+
+	if eof {
+		rb.cresp.EOF = true
+	}
+
+	rb.cas.mu.Unlock()
+
+	rb.pending = rb.pending[end:]
+}
+
+// lastEventEnd returns the length of the complete events at the start of buf
+func lastEventEnd(buf []byte) int {
+	ret := 0
+
+	for _, sep := range []string{"\n\n", "\r\n\r\n", "\r\r"} {
+		i := bytes.LastIndex(buf, []byte(sep))
+		if i != -1 && i+len(sep) > ret {
+			ret = i + len(sep)
+		}
+	}
+
+	return ret
+}
+
+// redactEvents redacts the data of each event in text, replacing the data
+// lines of any event that changes with a single compact one.
+func redactEvents(text string) string {
+	lines := []string{}
+
+	for text != "" {
+		end := len(text)
+
+		i := strings.IndexAny(text, "\r\n")
+		if i != -1 {
+			end = i + 1
+
+			if text[i] == '\r' && end < len(text) && text[end] == '\n' {
+				end++
+			}
+		}
+
+		lines = append(lines, text[:end])
+		text = text[end:]
+	}
+
+	ret := []string{}
+	event := []string{}
+	data := []string{}
+
+	flush := func() {
+		orig := strings.Join(data, "\n")
+		red := redactBody(orig)
+
+		if red == orig {
+			ret = append(ret, event...)
+		} else {
+			buf := &bytes.Buffer{}
+
+			if json.Compact(buf, []byte(red)) != nil {
+				buf.Reset()
+				buf.WriteString(redacted)
+			}
+
+			dataDone := false
+
+			for _, line := range event {
+				if !strings.HasPrefix(line, "data:") {
+					ret = append(ret, line)
+				} else if !dataDone {
+					ret = append(ret, "data: "+buf.String()+"\n")
+					dataDone = true
+				}
+			}
+		}
+
+		event = []string{}
+		data = []string{}
+	}
+
+	for _, line := range lines {
+		event = append(event, line)
+
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(trimmed, "data:"), " "))
+		}
+	}
+
+	flush()
+
+	return strings.Join(ret, "")
+}
+
+type replayBody struct {
+	chunks []*CassetteChunk
+	eof    bool
+	delays bool
+	buf    []byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newReplayBody(cresp *CassetteResponse, delays bool) *replayBody {
+	return &replayBody{
+		chunks: cresp.Chunks,
+		eof:    cresp.EOF,
+		delays: delays,
+		done:   make(chan struct{}),
+	}
+}
+
+func (rb *replayBody) Read(p []byte) (int, error) {
+	for len(rb.buf) == 0 {
+		if len(rb.chunks) == 0 {
+			if !rb.eof {
+				// The recording client closed the stream; hold it open until we do too
+				<-rb.done
+				return 0, io.ErrClosedPipe
+			}
+
+			return 0, io.EOF
+		}
+
+		chunk := rb.chunks[0]
+		rb.chunks = rb.chunks[1:]
+
+		if rb.delays && chunk.Delay > 0 {
+			t := time.NewTimer(chunk.Delay)
+
+			select {
+			case <-rb.done:
+				t.Stop()
+				return 0, io.ErrClosedPipe
+			case <-t.C:
+			}
+		}
+
+		rb.buf = []byte(chunk.Data)
+	}
+
+	select {
+	case <-rb.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	n := copy(p, rb.buf)
+	rb.buf = rb.buf[n:]
+
+	return n, nil
+}
+
+func (rb *replayBody) Close() error {
+	rb.closeOnce.Do(func() { close(rb.done) })
+	return nil
+}
+
+func (rb *replayBody) closeOnDone(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		rb.Close()
+	case <-rb.done:
+	}
+}
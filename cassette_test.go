@@ -0,0 +1,89 @@
+package gosolo_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+)
+
+func TestCassetteRedactsStreams(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Stream-Format", "full")
+		w.Header().Set("Set-Cookie", "session=cookie-secret")
+
+		// Split mid-event so redaction has to wait for the whole event
+		fmt.Fprint(w, "event: list\nid: e1\ndata: [{\"id\":\"t1\",")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "\"token\":\"token-secret\"}]\n\n")
+		w.(http.Flusher).Flush()
+
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	cas, err := gosolo.OpenCassette(path, gosolo.CassetteRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := gosolo.NewClientDirect(srv.URL).SetCassette(cas)
+
+	stream, err := c.StreamListToken(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := stream.Read()
+	if len(list) != 1 || list[0].Token != "token-secret" {
+		t.Fatalf("live stream: %+v", list)
+	}
+
+	stream.Close()
+
+	err = cas.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"token-secret", "cookie-secret"} {
+		if strings.Contains(string(js), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, js)
+		}
+	}
+
+	cas, err = gosolo.OpenCassette(path, gosolo.CassetteReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c = gosolo.NewClientDirect("http://replay.invalid").SetCassette(cas)
+
+	stream, err = c.StreamListToken(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer stream.Close()
+
+	list = stream.Read()
+	if len(list) != 1 || list[0].ID != "t1" || list[0].Token != "[REDACTED]" {
+		t.Fatalf("replayed stream: %+v", list)
+	}
+}
@@ -3,11 +3,9 @@
 package gosolo
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -15,56 +13,17 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	//
 	"github.com/go-resty/resty/v2"
 	"github.com/gopatchy/jsrest"
 	"github.com/gopatchy/metadata"
-	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
 )
 
-type ShardServerConfig struct {
-	metadata.Metadata
-
-	ListETag   string `json:"-"`
-	ShardID    string `json:"shardID,omitempty"`
-	InstanceID string `json:"instanceID,omitempty"`
-}
-
-type Task struct {
-	metadata.Metadata
-
-	ListETag string    `json:"-"`
-	UserID   string    `json:"userID,omitempty"`
-	Name     string    `json:"name,omitempty"`
-	Complete bool      `json:"complete,omitempty"`
-	After    time.Time `json:"after,omitempty"`
-}
-
-type Token struct {
-	metadata.Metadata
-
-	ListETag string `json:"-"`
-	UserID   string `json:"userID,omitempty"`
-	Token    string `json:"token,omitempty"`
-	Shard    string `json:"shard,omitempty"`
-}
-
-type User struct {
-	metadata.Metadata
-
-	ListETag          string `json:"-"`
-	Name              string `json:"name,omitempty"`
-	Email             string `json:"email,omitempty"`
-	Password          string `json:"password,omitempty"`
-	Shard             string `json:"shard,omitempty"`
-	ServiceAdmin      bool   `json:"serviceAdmin,omitempty"`
-	ReplicationClient bool   `json:"replicationClient,omitempty"`
-}
-
 type GetOpts[T any] struct {
 	Prev *T
 	// TODO: Add FailFast bool
@@ -90,16 +49,34 @@ type Filter struct {
 
 type UpdateOpts[T any] struct {
 	Prev *T
+
+	// Send only these JSON fields of obj, zero or not, as a merge patch.
+	// Only UpdateName takes it; ReplaceName and DeleteName fail with
+	// ErrFieldsUnsupported rather than send the whole object or ignore it.
+	Fields []string
 	// TODO: Add FailFast bool
 }
 
 type Client struct {
-	rst *resty.Client
+	rst       *resty.Client
+	transport *http.Transport
+	breakers  *breakerSet
+	logger    *slog.Logger
+	telemetry *telemetry
+	cassette  *Cassette
+
+	middleware []Middleware
+
+	streamMaxEventSize int
+	streamResume       bool
+
+	jsonPatchRejected atomic.Bool
 }
 
 var (
 	ErrNotFound            = fmt.Errorf("not found")
 	ErrMultipleFound       = fmt.Errorf("multiple found")
+	ErrFieldsUnsupported   = fmt.Errorf("UpdateOpts.Fields unsupported")
 	ErrInvalidStreamEvent  = fmt.Errorf("invalid stream event")
 	ErrInvalidStreamFormat = fmt.Errorf("invalid stream format")
 )
@@ -109,7 +86,16 @@ func NewClientDirect(baseURL string) *Client {
 
 	c.rst = resty.New().
 		SetHeader("Accept", "application/json").
-		SetJSONEscapeHTML(false)
+		SetJSONEscapeHTML(false).
+		OnRequestLog(redactRequestLog).
+		OnResponseLog(redactResponseLog)
+
+	c.transport, _ = c.rst.Transport()
+	if c.transport == nil {
+		c.transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	c.updateTransport()
 
 	c.SetBaseURL(baseURL)
 
@@ -135,7 +121,7 @@ func (c *Client) SetDebug(debug bool) *Client {
 }
 
 func (c *Client) SetTLSClientConfig(cfg *tls.Config) *Client {
-	c.rst.SetTLSClientConfig(cfg)
+	c.transport.TLSClientConfig = cfg
 	return c
 }
 
@@ -186,169 +172,11 @@ func (c *Client) TSClient(ctx context.Context) (string, error) {
 	return c.fetchString(ctx, "_client.ts")
 }
 
-//// ShardServerConfig
-
-func (c *Client) CreateShardServerConfig(ctx context.Context, obj *ShardServerConfig) (*ShardServerConfig, error) {
-	// TODO: Take CreateOpts (with at least FailFast)
-	return CreateName[ShardServerConfig](ctx, c, "shardserverconfig", obj)
-}
-
-func (c *Client) DeleteShardServerConfig(ctx context.Context, id string, opts *UpdateOpts[ShardServerConfig]) error {
-	return DeleteName[ShardServerConfig](ctx, c, "shardserverconfig", id, opts)
-}
-
-func (c *Client) FindShardServerConfig(ctx context.Context, shortID string) (*ShardServerConfig, error) {
-	// TODO: Take GetOpts or something (for FailFast)
-	return FindName[ShardServerConfig](ctx, c, "shardserverconfig", shortID)
-}
-
-func (c *Client) GetShardServerConfig(ctx context.Context, id string, opts *GetOpts[ShardServerConfig]) (*ShardServerConfig, error) {
-	return GetName[ShardServerConfig](ctx, c, "shardserverconfig", id, opts)
-}
-
-func (c *Client) ListShardServerConfig(ctx context.Context, opts *ListOpts[ShardServerConfig]) ([]*ShardServerConfig, error) {
-	return ListName[ShardServerConfig](ctx, c, "shardserverconfig", opts)
-}
-
-func (c *Client) ReplaceShardServerConfig(ctx context.Context, id string, obj *ShardServerConfig, opts *UpdateOpts[ShardServerConfig]) (*ShardServerConfig, error) {
-	return ReplaceName[ShardServerConfig](ctx, c, "shardserverconfig", id, obj, opts)
-}
-
-func (c *Client) UpdateShardServerConfig(ctx context.Context, id string, obj *ShardServerConfig, opts *UpdateOpts[ShardServerConfig]) (*ShardServerConfig, error) {
-	return UpdateName[ShardServerConfig](ctx, c, "shardserverconfig", id, obj, opts)
-}
-
-func (c *Client) StreamGetShardServerConfig(ctx context.Context, id string, opts *GetOpts[ShardServerConfig]) (*GetStream[ShardServerConfig], error) {
-	return StreamGetName[ShardServerConfig](ctx, c, "shardserverconfig", id, opts)
-}
-
-func (c *Client) StreamListShardServerConfig(ctx context.Context, opts *ListOpts[ShardServerConfig]) (*ListStream[ShardServerConfig], error) {
-	return StreamListName[ShardServerConfig](ctx, c, "shardserverconfig", opts)
-}
-
-//// Task
-
-func (c *Client) CreateTask(ctx context.Context, obj *Task) (*Task, error) {
-	// TODO: Take CreateOpts (with at least FailFast)
-	return CreateName[Task](ctx, c, "task", obj)
-}
-
-func (c *Client) DeleteTask(ctx context.Context, id string, opts *UpdateOpts[Task]) error {
-	return DeleteName[Task](ctx, c, "task", id, opts)
-}
-
-func (c *Client) FindTask(ctx context.Context, shortID string) (*Task, error) {
-	// TODO: Take GetOpts or something (for FailFast)
-	return FindName[Task](ctx, c, "task", shortID)
-}
-
-func (c *Client) GetTask(ctx context.Context, id string, opts *GetOpts[Task]) (*Task, error) {
-	return GetName[Task](ctx, c, "task", id, opts)
-}
-
-func (c *Client) ListTask(ctx context.Context, opts *ListOpts[Task]) ([]*Task, error) {
-	return ListName[Task](ctx, c, "task", opts)
-}
-
-func (c *Client) ReplaceTask(ctx context.Context, id string, obj *Task, opts *UpdateOpts[Task]) (*Task, error) {
-	return ReplaceName[Task](ctx, c, "task", id, obj, opts)
-}
-
-func (c *Client) UpdateTask(ctx context.Context, id string, obj *Task, opts *UpdateOpts[Task]) (*Task, error) {
-	return UpdateName[Task](ctx, c, "task", id, obj, opts)
-}
-
-func (c *Client) StreamGetTask(ctx context.Context, id string, opts *GetOpts[Task]) (*GetStream[Task], error) {
-	return StreamGetName[Task](ctx, c, "task", id, opts)
-}
-
-func (c *Client) StreamListTask(ctx context.Context, opts *ListOpts[Task]) (*ListStream[Task], error) {
-	return StreamListName[Task](ctx, c, "task", opts)
-}
-
-//// Token
-
-func (c *Client) CreateToken(ctx context.Context, obj *Token) (*Token, error) {
-	// TODO: Take CreateOpts (with at least FailFast)
-	return CreateName[Token](ctx, c, "token", obj)
-}
-
-func (c *Client) DeleteToken(ctx context.Context, id string, opts *UpdateOpts[Token]) error {
-	return DeleteName[Token](ctx, c, "token", id, opts)
-}
-
-func (c *Client) FindToken(ctx context.Context, shortID string) (*Token, error) {
-	// TODO: Take GetOpts or something (for FailFast)
-	return FindName[Token](ctx, c, "token", shortID)
-}
-
-func (c *Client) GetToken(ctx context.Context, id string, opts *GetOpts[Token]) (*Token, error) {
-	return GetName[Token](ctx, c, "token", id, opts)
-}
-
-func (c *Client) ListToken(ctx context.Context, opts *ListOpts[Token]) ([]*Token, error) {
-	return ListName[Token](ctx, c, "token", opts)
-}
-
-func (c *Client) ReplaceToken(ctx context.Context, id string, obj *Token, opts *UpdateOpts[Token]) (*Token, error) {
-	return ReplaceName[Token](ctx, c, "token", id, obj, opts)
-}
-
-func (c *Client) UpdateToken(ctx context.Context, id string, obj *Token, opts *UpdateOpts[Token]) (*Token, error) {
-	return UpdateName[Token](ctx, c, "token", id, obj, opts)
-}
-
-func (c *Client) StreamGetToken(ctx context.Context, id string, opts *GetOpts[Token]) (*GetStream[Token], error) {
-	return StreamGetName[Token](ctx, c, "token", id, opts)
-}
-
-func (c *Client) StreamListToken(ctx context.Context, opts *ListOpts[Token]) (*ListStream[Token], error) {
-	return StreamListName[Token](ctx, c, "token", opts)
-}
-
-//// User
-
-func (c *Client) CreateUser(ctx context.Context, obj *User) (*User, error) {
-	// TODO: Take CreateOpts (with at least FailFast)
-	return CreateName[User](ctx, c, "user", obj)
-}
-
-func (c *Client) DeleteUser(ctx context.Context, id string, opts *UpdateOpts[User]) error {
-	return DeleteName[User](ctx, c, "user", id, opts)
-}
-
-func (c *Client) FindUser(ctx context.Context, shortID string) (*User, error) {
-	// TODO: Take GetOpts or something (for FailFast)
-	return FindName[User](ctx, c, "user", shortID)
-}
-
-func (c *Client) GetUser(ctx context.Context, id string, opts *GetOpts[User]) (*User, error) {
-	return GetName[User](ctx, c, "user", id, opts)
-}
-
-func (c *Client) ListUser(ctx context.Context, opts *ListOpts[User]) ([]*User, error) {
-	return ListName[User](ctx, c, "user", opts)
-}
-
-func (c *Client) ReplaceUser(ctx context.Context, id string, obj *User, opts *UpdateOpts[User]) (*User, error) {
-	return ReplaceName[User](ctx, c, "user", id, obj, opts)
-}
-
-func (c *Client) UpdateUser(ctx context.Context, id string, obj *User, opts *UpdateOpts[User]) (*User, error) {
-	return UpdateName[User](ctx, c, "user", id, obj, opts)
-}
-
-func (c *Client) StreamGetUser(ctx context.Context, id string, opts *GetOpts[User]) (*GetStream[User], error) {
-	return StreamGetName[User](ctx, c, "user", id, opts)
-}
-
-func (c *Client) StreamListUser(ctx context.Context, opts *ListOpts[User]) (*ListStream[User], error) {
-	return StreamListName[User](ctx, c, "user", opts)
-}
-
 //// Generic
 
 func CreateName[T any](ctx context.Context, c *Client, name string, obj *T) (*T, error) {
+	ctx = withOperation(ctx, "CreateName")
+
 	created := new(T)
 
 	// TODO: Set Idempotency-Key
@@ -372,6 +200,12 @@ func CreateName[T any](ctx context.Context, c *Client, name string, obj *T) (*T,
 }
 
 func DeleteName[T any](ctx context.Context, c *Client, name, id string, opts *UpdateOpts[T]) error {
+	ctx = withOperation(ctx, "DeleteName")
+
+	if opts != nil && len(opts.Fields) > 0 {
+		return fmt.Errorf("DeleteName (%w)", ErrFieldsUnsupported)
+	}
+
 	r := c.rst.R().
 		SetContext(ctx).
 		SetPathParam("name", name).
@@ -395,6 +229,8 @@ func DeleteName[T any](ctx context.Context, c *Client, name, id string, opts *Up
 }
 
 func FindName[T any](ctx context.Context, c *Client, name, shortID string) (*T, error) {
+	ctx = withOperation(ctx, "FindName")
+
 	listOpts := &ListOpts[T]{
 		Filters: []Filter{
 			{
@@ -415,13 +251,25 @@ func FindName[T any](ctx context.Context, c *Client, name, shortID string) (*T,
 	}
 
 	if len(objs) > 1 {
-		return nil, fmt.Errorf("%s (%w)", shortID, ErrMultipleFound)
+		// A full ID also prefixes any longer IDs that start with it
+		for _, obj := range objs {
+			if metadata.GetMetadata(obj).ID == shortID {
+				return obj, nil
+			}
+		}
+
+		return nil, &MultipleFoundError[T]{
+			ShortID:    shortID,
+			Candidates: objs,
+		}
 	}
 
 	return objs[0], nil
 }
 
 func GetName[T any](ctx context.Context, c *Client, name, id string, opts *GetOpts[T]) (*T, error) {
+	ctx = withOperation(ctx, "GetName")
+
 	obj := new(T)
 
 	r := c.rst.R().
@@ -455,6 +303,8 @@ func GetName[T any](ctx context.Context, c *Client, name, id string, opts *GetOp
 }
 
 func ListName[T any](ctx context.Context, c *Client, name string, opts *ListOpts[T]) ([]*T, error) {
+	ctx = withOperation(ctx, "ListName")
+
 	objs := []*T{}
 
 	// TODO: Split out ListNameOnce, add retry loop
@@ -488,6 +338,12 @@ func ListName[T any](ctx context.Context, c *Client, name string, opts *ListOpts
 }
 
 func ReplaceName[T any](ctx context.Context, c *Client, name, id string, obj *T, opts *UpdateOpts[T]) (*T, error) {
+	ctx = withOperation(ctx, "ReplaceName")
+
+	if opts != nil && len(opts.Fields) > 0 {
+		return nil, fmt.Errorf("ReplaceName (%w)", ErrFieldsUnsupported)
+	}
+
 	replaced := new(T)
 
 	// TODO: Set Idempotency-Key
@@ -515,6 +371,17 @@ func ReplaceName[T any](ctx context.Context, c *Client, name, id string, obj *T,
 }
 
 func UpdateName[T any](ctx context.Context, c *Client, name, id string, obj *T, opts *UpdateOpts[T]) (*T, error) {
+	ctx = withOperation(ctx, "UpdateName")
+
+	if opts != nil && len(opts.Fields) > 0 {
+		patch, err := PatchFields(obj, opts.Fields...)
+		if err != nil {
+			return nil, err
+		}
+
+		return PatchName[T](ctx, c, name, id, patch, opts)
+	}
+
 	updated := new(T)
 
 	// TODO: Set Idempotency-Key
@@ -542,6 +409,8 @@ func UpdateName[T any](ctx context.Context, c *Client, name, id string, obj *T,
 }
 
 func StreamGetName[T any](ctx context.Context, c *Client, name, id string, opts *GetOpts[T]) (*GetStream[T], error) {
+	ctx = withOperation(ctx, "StreamGetName")
+
 	r := c.rst.R().
 		SetContext(ctx).
 		SetDoNotParseResponse(true).
@@ -562,9 +431,13 @@ func StreamGetName[T any](ctx context.Context, c *Client, name, id string, opts
 		return nil, jsrest.ReadError(resp)
 	}
 
+	c.logStream(ctx, slog.LevelDebug, "stream connected", name, slog.String("id", id))
+
 	stream := &GetStream[T]{
-		ch:   make(chan *T, 100),
-		body: resp.RawBody(),
+		ch:     make(chan *T, 100),
+		body:   resp.RawBody(),
+		client: c,
+		name:   name,
 	}
 
 	if opts != nil && opts.Prev != nil {
@@ -577,11 +450,16 @@ func StreamGetName[T any](ctx context.Context, c *Client, name, id string, opts
 }
 
 func StreamListName[T any](ctx context.Context, c *Client, name string, opts *ListOpts[T]) (*ListStream[T], error) {
+	ctx = withOperation(ctx, "StreamListName")
+
 	ctx, cancel := context.WithCancel(ctx)
 
 	stream := &ListStream[T]{
 		ch:     make(chan []*T, 100),
 		cancel: cancel,
+		es:     newEventStream[T](c.streamMaxEventSize),
+		client: c,
+		name:   name,
 	}
 
 	if opts != nil {
@@ -593,15 +471,33 @@ func StreamListName[T any](ctx context.Context, c *Client, name string, opts *Li
 	go func() {
 		defer close(stream.ch)
 
-		for ctx.Err() == nil {
-			err := streamListNameOnce[T](ctx, c, name, opts, stream)
+		defer c.logStream(ctx, slog.LevelDebug, "stream closed", name)
+
+		for attempt := 1; ctx.Err() == nil; attempt++ {
+			c.waitBreaker(ctx)
+
+			if attempt > 1 {
+				c.recordReconnect(ctx, name)
+			}
+
+			err := streamListNameOnce[T](withAttempt(ctx, attempt), c, name, opts, stream)
 			stream.writeError(err)
 
+			if ctx.Err() == nil {
+				c.logStream(ctx, slog.LevelWarn, "stream disconnected", name, slog.Int("attempt", attempt), slog.Any("error", err))
+			}
+
 			hErr := jsrest.GetHTTPError(err)
 			if hErr != nil && hErr.Code/100 == 4 {
 				break
 			}
 
+			if errors.Is(err, ErrCircuitOpen) {
+				// waitBreaker paces retries while the circuit is open
+				continue
+			}
+
+			b.retry = stream.es.retry
 			b.failure(ctx)
 		}
 	}()
@@ -627,23 +523,45 @@ func streamListNameOnce[T any](ctx context.Context, c *Client, name string, opts
 		return err
 	}
 
+	if stream.syncID != "" {
+		r.SetHeader("Last-Event-ID", stream.syncID)
+	}
+
 	resp, err := r.Get("{name}")
 	if err != nil {
 		return err
 	}
 
 	if resp.IsError() {
+		if stream.syncID != "" && resp.StatusCode()/100 == 4 {
+			// Can't resume from there, so start over
+			resp.RawBody().Close()
+
+			stream.synced = nil
+			stream.syncID = ""
+
+			return streamListNameOnce(ctx, c, name, opts, stream)
+		}
+
 		return jsrest.ReadError(resp)
 	}
 
 	stream.reset(resp.RawBody())
 
+	c.logStream(ctx, slog.LevelDebug, "stream connected", name, slog.String("format", resp.Header().Get("Stream-Format")))
+
 	switch resp.Header().Get("Stream-Format") {
 	case "full":
 		return stream.processFull()
 
 	case "diff":
-		return stream.processDiff()
+		// See SetStreamResume; anything else starts over from an empty list
+		if stream.syncID != "" && resp.Header().Get("Stream-Resume") == stream.syncID {
+			c.logStream(ctx, slog.LevelDebug, "stream resumed", name, slog.String("id", stream.syncID))
+			return stream.processDiff(stream.synced)
+		}
+
+		return stream.processDiff(nil)
 
 	default:
 		stream.Close()
@@ -651,86 +569,12 @@ func streamListNameOnce[T any](ctx context.Context, c *Client, name string, opts
 	}
 }
 
-type streamEvent[T any] struct {
-	eventType string
-	params    map[string]string
-	data      []byte
-}
-
-func newStreamEvent[T any]() *streamEvent[T] {
-	return &streamEvent[T]{
-		params: map[string]string{},
-	}
-}
-
-func (ev *streamEvent[T]) decodeObj() (*T, error) {
-	obj := new(T)
-
-	err := json.Unmarshal(ev.data, obj)
-	if err != nil {
-		return nil, err
-	}
-
-	return obj, nil
-}
-
-func (ev *streamEvent[T]) decodeList() ([]*T, error) {
-	list := []*T{}
-
-	err := json.Unmarshal(ev.data, &list)
-	if err != nil {
-		return nil, err
-	}
-
-	return list, nil
-}
-
-type eventStream[T any] struct {
-	scan *bufio.Scanner
-}
-
-func newEventStream[T any](scan *bufio.Scanner) *eventStream[T] {
-	return &eventStream[T]{
-		scan: scan,
-	}
-}
-
-func (es *eventStream[T]) readEvent() (*streamEvent[T], error) {
-	event := newStreamEvent[T]()
-	data := [][]byte{}
-
-	// TODO: Add a timeout (15s?) here that causes us to return error, closing the stream
-
-	for es.scan.Scan() {
-		line := es.scan.Text()
-
-		switch {
-		case strings.HasPrefix(line, ":"):
-			continue
-
-		case strings.HasPrefix(line, "event: "):
-			event.eventType = strings.TrimPrefix(line, "event: ")
-
-		case strings.HasPrefix(line, "data: "):
-			data = append(data, bytes.TrimPrefix(es.scan.Bytes(), []byte("data: ")))
-
-		case line == "":
-			event.data = bytes.Join(data, []byte("\n"))
-			return event, nil
-
-		case strings.Contains(line, ": "):
-			parts := strings.SplitN(line, ": ", 2)
-			event.params[parts[0]] = parts[1]
-		}
-	}
-
-	return nil, io.EOF
-}
-
 type GetStream[T any] struct {
-	ch   chan *T
-	body io.ReadCloser
-	prev *T
+	ch     chan *T
+	body   io.ReadCloser
+	prev   *T
+	client *Client
+	name   string
 
 	lastEventReceived time.Time
 	err               error
@@ -764,8 +608,8 @@ func (gs *GetStream[T]) Error() error {
 }
 
 func (gs *GetStream[T]) process() {
-	scan := bufio.NewScanner(gs.body)
-	es := newEventStream[T](scan)
+	es := newEventStream[T](gs.client.streamMaxEventSize)
+	es.reset(gs.body)
 
 	for {
 		event, err := es.readEvent()
@@ -774,6 +618,8 @@ func (gs *GetStream[T]) process() {
 			return
 		}
 
+		gs.client.recordStreamEvent(context.Background(), gs.name, event.eventType)
+
 		switch event.eventType {
 		case "initial":
 			fallthrough
@@ -826,11 +672,18 @@ type ListStream[T any] struct {
 	ch     chan []*T
 	cancel context.CancelFunc
 	body   io.ReadCloser
+	es     *eventStream[T]
 	prev   []*T
+	client *Client
+	name   string
 
 	lastEventReceived time.Time
 	lastETag          string
 
+	// Last diff sync, to resume from if SetStreamResume
+	synced []*T
+	syncID string
+
 	err error
 
 	mu sync.RWMutex
@@ -844,6 +697,8 @@ func (ls *ListStream[T]) Close() {
 	}
 }
 
+// Chan returns successive lists. Objects that didn't change are shared
+// between lists, so treat them as read-only.
 func (ls *ListStream[T]) Chan() <-chan []*T {
 	return ls.ch
 }
@@ -868,12 +723,12 @@ func (ls *ListStream[T]) Error() error {
 
 func (ls *ListStream[T]) reset(body io.ReadCloser) {
 	ls.body = body
+	ls.es.reset(body)
 	ls.err = nil
 }
 
 func (ls *ListStream[T]) processFull() error {
-	scan := bufio.NewScanner(ls.body)
-	es := newEventStream[T](scan)
+	es := ls.es
 
 	for {
 		event, err := es.readEvent()
@@ -881,6 +736,8 @@ func (ls *ListStream[T]) processFull() error {
 			return err
 		}
 
+		ls.client.recordStreamEvent(context.Background(), ls.name, event.eventType)
+
 		switch event.eventType {
 		case "list":
 			list, err := event.decodeList()
@@ -900,10 +757,9 @@ func (ls *ListStream[T]) processFull() error {
 	}
 }
 
-func (ls *ListStream[T]) processDiff() error {
-	scan := bufio.NewScanner(ls.body)
-	es := newEventStream[T](scan)
-	list := []*T{}
+func (ls *ListStream[T]) processDiff(start []*T) error {
+	es := ls.es
+	list := newPosList(start)
 
 	add := func(event *streamEvent[T]) error {
 		obj, err := event.decodeObj()
@@ -916,9 +772,7 @@ func (ls *ListStream[T]) processDiff() error {
 			return err
 		}
 
-		list = slices.Insert(list, pos, obj)
-
-		return nil
+		return list.Insert(pos, obj)
 	}
 
 	remove := func(event *streamEvent[T]) error {
@@ -927,9 +781,7 @@ func (ls *ListStream[T]) processDiff() error {
 			return err
 		}
 
-		list = slices.Delete(list, pos, pos+1)
-
-		return nil
+		return list.Delete(pos)
 	}
 
 	for {
@@ -938,6 +790,8 @@ func (ls *ListStream[T]) processDiff() error {
 			return err
 		}
 
+		ls.client.recordStreamEvent(context.Background(), ls.name, event.eventType)
+
 		switch event.eventType {
 		case "add":
 			err = add(event)
@@ -963,26 +817,19 @@ func (ls *ListStream[T]) processDiff() error {
 			}
 
 		case "sync":
-			setListETag(list, fmt.Sprintf(`"%s"`, event.params["id"]))
+			tmp := list.snapshot(fmt.Sprintf(`"%s"`, event.params["id"]))
 
-			// Write a copy since we mutate list
-			tmp, err := ls.clone(list)
-			if err != nil {
-				return err
+			if ls.client.streamResume {
+				ls.synced = tmp
+				ls.syncID = event.params["id"]
 			}
 
 			ls.writeEvent(tmp)
 
 		case "notModified":
-			list = ls.prev
+			list = newPosList(ls.prev)
 
-			// Write a copy since we mutate list
-			tmp, err := ls.clone(list)
-			if err != nil {
-				return err
-			}
-
-			ls.writeEvent(tmp)
+			ls.writeEvent(list.snapshot(getListETag(ls.prev)))
 
 		case "heartbeat":
 			ls.writeHeartbeat()
@@ -1019,24 +866,6 @@ func (ls *ListStream[T]) writeError(err error) {
 	ls.mu.Unlock()
 }
 
-func (ls *ListStream[T]) clone(list []*T) ([]*T, error) {
-	js, err := json.Marshal(list)
-	if err != nil {
-		return nil, err
-	}
-
-	ret := []*T{}
-
-	err = json.Unmarshal(js, &ret)
-	if err != nil {
-		return nil, err
-	}
-
-	setListETag(ret, getListETag(list))
-
-	return ret, nil
-}
-
 //// Internal
 
 func (opts *GetOpts[T]) apply(req *resty.Request) {
@@ -1158,6 +987,9 @@ func getListETagField[T any](list []*T) reflect.Value {
 type backoff struct {
 	delay       time.Duration
 	lastFailure time.Time
+
+	// From the server's retry: field
+	retry time.Duration
 }
 
 const (
@@ -1189,6 +1021,11 @@ func (b *backoff) failure(ctx context.Context) {
 	// Full jitter
 	actualDelay := time.Duration(rand.Int63n(int64(b.delay))) //nolint:gosec
 
+	// But never sooner than the server asked
+	if actualDelay < b.retry {
+		actualDelay = b.retry
+	}
+
 	t := time.NewTimer(actualDelay)
 
 	select {
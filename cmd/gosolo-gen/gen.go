@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+
+	"github.com/tasksolo/gosolo/internal/openapi"
+)
+
+type Options struct {
+	Package string
+
+	// API name -> Go type name overrides
+	TypeNames map[string]string
+}
+
+type templateData struct {
+	Package   string
+	External  bool
+	NeedsTime bool
+	Resources []*openapi.Resource
+}
+
+// Generate returns gofmt'd Go source for every resource in doc. Output
+// depends only on doc and opts.
+func Generate(doc map[string]any, opts *Options) ([]byte, error) {
+	resources, err := openapi.Resources(doc, opts.TypeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &templateData{
+		Package:   opts.Package,
+		External:  opts.Package != "gosolo",
+		Resources: resources,
+	}
+
+	for _, res := range resources {
+		for _, field := range res.Fields {
+			if strings.Contains(field.GoType, "time.Time") {
+				data.NeedsTime = true
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	err = tmpl.Execute(buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go (%w)", err)
+	}
+
+	return src, nil
+}
+
+var tmpl = template.Must(template.New("client").Parse(`// Code generated by gosolo-gen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"context"
+{{- if not .External }}
+	"reflect"
+{{- end }}
+{{- if .NeedsTime }}
+	"time"
+{{- end }}
+
+	"github.com/gopatchy/metadata"
+{{- if .External }}
+	"github.com/tasksolo/gosolo"
+{{- end }}
+)
+{{ $g := "" }}{{ if .External }}{{ $g = "gosolo." }}
+// Client adds typed methods for the resources below to gosolo.Client.
+type Client struct {
+	*gosolo.Client
+}
+
+func NewClient(c *gosolo.Client) *Client {
+	return &Client{
+		Client: c,
+	}
+}
+{{ end }}
+{{- range .Resources }}
+
+type {{ .TypeName }} struct {
+	metadata.Metadata
+
+	ListETag string ` + "`json:\"-\"`" + `
+{{- range .Fields }}
+	{{ .GoName }} {{ .GoType }} ` + "`json:\"{{ .JSONName }},omitempty\"`" + `
+{{- end }}
+
+	Unknown {{ $g }}Unknown ` + "`json:\"-\"`" + `
+}
+
+func (obj *{{ .TypeName }}) UnmarshalJSON(data []byte) error {
+	type plain {{ .TypeName }}
+	return {{ $g }}UnmarshalWithUnknown(data, (*plain)(obj), &obj.Unknown)
+}
+
+func (obj {{ .TypeName }}) MarshalJSON() ([]byte, error) {
+	type plain {{ .TypeName }}
+	return {{ $g }}MarshalWithUnknown(plain(obj), obj.Unknown)
+}
+{{- end }}
+{{- if not .External }}
+
+// resourceTypes maps API names to their types, for CheckSchema
+var resourceTypes = map[string]reflect.Type{
+{{- range .Resources }}
+	"{{ .Name }}": reflect.TypeOf({{ .TypeName }}{}),
+{{- end }}
+}
+{{- end }}
+{{- range .Resources }}
+{{ $t := .TypeName }}{{ $n := .Name }}
+//// {{ $t }}
+
+func (c *Client) Create{{ $t }}(ctx context.Context, obj *{{ $t }}) (*{{ $t }}, error) {
+	return {{ $g }}CreateName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", obj)
+}
+
+func (c *Client) Delete{{ $t }}(ctx context.Context, id string, opts *{{ $g }}UpdateOpts[{{ $t }}]) error {
+	return {{ $g }}DeleteName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", id, opts)
+}
+
+func (c *Client) DiffUpdate{{ $t }}(ctx context.Context, prev, next *{{ $t }}) (*{{ $t }}, error) {
+	return {{ $g }}DiffUpdateName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", prev, next)
+}
+
+func (c *Client) Find{{ $t }}(ctx context.Context, shortID string) (*{{ $t }}, error) {
+	return {{ $g }}FindName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", shortID)
+}
+
+func (c *Client) Get{{ $t }}(ctx context.Context, id string, opts *{{ $g }}GetOpts[{{ $t }}]) (*{{ $t }}, error) {
+	return {{ $g }}GetName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", id, opts)
+}
+
+func (c *Client) List{{ $t }}(ctx context.Context, opts *{{ $g }}ListOpts[{{ $t }}]) ([]*{{ $t }}, error) {
+	return {{ $g }}ListName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", opts)
+}
+
+func (c *Client) Replace{{ $t }}(ctx context.Context, id string, obj *{{ $t }}, opts *{{ $g }}UpdateOpts[{{ $t }}]) (*{{ $t }}, error) {
+	return {{ $g }}ReplaceName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", id, obj, opts)
+}
+
+func (c *Client) Update{{ $t }}(ctx context.Context, id string, obj *{{ $t }}, opts *{{ $g }}UpdateOpts[{{ $t }}]) (*{{ $t }}, error) {
+	return {{ $g }}UpdateName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", id, obj, opts)
+}
+
+func (c *Client) Patch{{ $t }}(ctx context.Context, id string, patch {{ $g }}Patch, opts *{{ $g }}UpdateOpts[{{ $t }}]) (*{{ $t }}, error) {
+	return {{ $g }}PatchName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", id, patch, opts)
+}
+
+func (c *Client) StreamGet{{ $t }}(ctx context.Context, id string, opts *{{ $g }}GetOpts[{{ $t }}]) (*{{ $g }}GetStream[{{ $t }}], error) {
+	return {{ $g }}StreamGetName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", id, opts)
+}
+
+func (c *Client) StreamList{{ $t }}(ctx context.Context, opts *{{ $g }}ListOpts[{{ $t }}]) (*{{ $g }}ListStream[{{ $t }}], error) {
+	return {{ $g }}StreamListName[{{ $t }}](ctx, c{{ if $g }}.Client{{ end }}, "{{ $n }}", opts)
+}
+{{- end }}
+`))
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+// TestGenerateGolden generates each testdata/<name>.json as package gosolo
+// (<name>.golden) and as an external package (<name>.external.golden).
+func TestGenerateGolden(t *testing.T) {
+	t.Parallel()
+
+	paths, err := filepath.Glob("testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, path := range paths {
+		base := strings.TrimSuffix(path, ".json")
+
+		js, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, pkg := range []string{"gosolo", "external"} {
+			doc := map[string]any{}
+
+			err = json.Unmarshal(js, &doc)
+			if err != nil {
+				t.Fatalf("%s: %s", path, err)
+			}
+
+			src, err := Generate(doc, &Options{
+				Package: pkg,
+			})
+			if err != nil {
+				t.Fatalf("%s: %s", path, err)
+			}
+
+			golden := base + ".golden"
+			if pkg != "gosolo" {
+				golden = base + "." + pkg + ".golden"
+			}
+
+			if *update {
+				err = os.WriteFile(golden, src, 0o644) //nolint:gosec
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				continue
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%s (run go test -update)", err)
+			}
+
+			if !bytes.Equal(src, want) {
+				t.Errorf("%s differs from generated output (run go test -update to accept):\n%s", golden, src)
+			}
+		}
+	}
+}
+
+// TestResourcesCurrent keeps the checked-in resources.go in step with the
+// template and openapi.json, the live schema that just update-client
+// fetches. The goldens instead pin the template against fixed testdata.
+func TestResourcesCurrent(t *testing.T) {
+	t.Parallel()
+
+	js, err := os.ReadFile("../../openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := map[string]any{}
+
+	err = json.Unmarshal(js, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := Generate(doc, &Options{
+		Package: "gosolo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile("../../resources.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Error("resources.go differs from openapi.json (run go run ./cmd/gosolo-gen -in openapi.json -out resources.go)")
+	}
+}
+
+func TestGenerateTypeNames(t *testing.T) {
+	t.Parallel()
+
+	js, err := os.ReadFile("testdata/types.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := map[string]any{}
+
+	err = json.Unmarshal(js, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Generate(doc, &Options{
+		Package:   "gosolo",
+		TypeNames: map[string]string{"task": "Todo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"type Todo struct",
+		`CreateName[Todo](ctx, c, "task", obj)`,
+		`"task":              reflect.TypeOf(Todo{}),`,
+		"type ShardServerConfig struct",
+		"Weight     int32",
+		"Tags     []string",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// gosolo-health checks that a server is reachable and that its schema
+// matches the compiled client.
+//
+// Usage:
+//
+//	gosolo-health -url https://api.example.com
+//
+// It exits 0 when healthy, 1 on schema drift and 2 if the check itself
+// fails.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+func main() {
+	url := flag.String("url", "", "API server URL, without /v1")
+	token := flag.String("token", "", "auth token")
+	timeout := flag.Duration("timeout", 30*time.Second, "give up after this long")
+	flag.Parse()
+
+	if *url == "" {
+		fmt.Fprintln(os.Stderr, "gosolo-health: -url is required")
+		os.Exit(2)
+	}
+
+	c := gosolo.NewClientDirect(*url)
+
+	if *token != "" {
+		c.SetAuthToken(*token)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	diffs, err := c.CheckSchema(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gosolo-health: %s\n", err)
+		os.Exit(2) //nolint:gocritic
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("ok: schema matches")
+		return
+	}
+
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+
+	os.Exit(1)
+}
@@ -6,10 +6,18 @@ require (
 	github.com/go-resty/resty/v2 v2.13.1
 	github.com/gopatchy/jsrest v0.0.0-20230617154508-e18710a310af
 	github.com/gopatchy/metadata v0.0.0-20230611025918-a5568e41335d
+	go.opentelemetry.io/otel v1.16.0
+	go.opentelemetry.io/otel/metric v1.16.0
+	go.opentelemetry.io/otel/sdk v1.16.0
+	go.opentelemetry.io/otel/sdk/metric v0.39.0
+	go.opentelemetry.io/otel/trace v1.16.0
 	golang.org/x/exp v0.0.0-20230522175609-2e198f4a06a1
 )
 
 require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/vfaronov/httpheader v0.1.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
 )
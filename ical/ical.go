@@ -0,0 +1,391 @@
+// Package ical converts between Solø tasks and iCalendar (RFC 5545) VTODO
+// components.
+//
+// Name maps to SUMMARY, After to DTSTART, Complete to STATUS:COMPLETED and
+// the metadata ID to UID. SEQUENCE must be an integer, so it carries the
+// metadata Generation (which advances with every ETag change); the ETag
+// itself travels in X-SOLO-ETAG.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+const (
+	prodID = "-//tasksolo//gosolo//EN"
+
+	utcLayout   = "20060102T150405Z"
+	localLayout = "20060102T150405"
+	dateLayout  = "20060102"
+
+	// Octets per line before folding
+	maxLineLen = 75
+)
+
+var ErrInvalidCalendar = fmt.Errorf("invalid iCalendar data")
+
+type component struct {
+	name       string
+	props      []*property
+	components []*component
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func WriteCalendar(w io.Writer, list []*gosolo.Task) error {
+	cal := &component{
+		name: "VCALENDAR",
+		props: []*property{
+			{name: "VERSION", value: "2.0"},
+			{name: "PRODID", value: prodID},
+		},
+	}
+
+	stamp := time.Now()
+
+	for _, task := range list {
+		cal.components = append(cal.components, taskToVTODO(task, stamp))
+	}
+
+	bw := bufio.NewWriter(w)
+
+	err := cal.write(bw)
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// ReadCalendar returns a task for each VTODO in r; other components are
+// ignored.
+func ReadCalendar(r io.Reader) ([]*gosolo.Task, error) {
+	cals, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*gosolo.Task{}
+
+	for _, cal := range cals {
+		if cal.name != "VCALENDAR" {
+			return nil, fmt.Errorf("unexpected %s (%w)", cal.name, ErrInvalidCalendar)
+		}
+
+		for _, comp := range cal.components {
+			if comp.name != "VTODO" {
+				continue
+			}
+
+			task, err := vtodoToTask(comp)
+			if err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, task)
+		}
+	}
+
+	return ret, nil
+}
+
+func taskToVTODO(task *gosolo.Task, stamp time.Time) *component {
+	todo := &component{
+		name: "VTODO",
+		props: []*property{
+			{name: "UID", value: task.ID},
+			{name: "DTSTAMP", value: stamp.UTC().Format(utcLayout)},
+			{name: "SUMMARY", value: escape(task.Name)},
+			{name: "SEQUENCE", value: strconv.FormatInt(task.Generation, 10)},
+		},
+	}
+
+	if !task.After.IsZero() {
+		todo.props = append(todo.props, &property{name: "DTSTART", value: task.After.UTC().Format(utcLayout)})
+	}
+
+	if task.Complete {
+		todo.props = append(todo.props, &property{name: "STATUS", value: "COMPLETED"})
+	} else {
+		todo.props = append(todo.props, &property{name: "STATUS", value: "NEEDS-ACTION"})
+	}
+
+	if task.ETag != "" {
+		todo.props = append(todo.props, &property{name: "X-SOLO-ETAG", value: escape(task.ETag)})
+	}
+
+	return todo
+}
+
+func vtodoToTask(todo *component) (*gosolo.Task, error) {
+	task := &gosolo.Task{}
+
+	for _, prop := range todo.props {
+		var err error
+
+		switch prop.name {
+		case "UID":
+			task.ID = unescape(prop.value)
+
+		case "SUMMARY":
+			task.Name = unescape(prop.value)
+
+		case "DTSTART":
+			task.After, err = parseTime(prop)
+
+		case "STATUS":
+			task.Complete = strings.EqualFold(prop.value, "COMPLETED")
+
+		case "COMPLETED":
+			task.Complete = true
+
+		case "SEQUENCE":
+			task.Generation, err = strconv.ParseInt(prop.value, 10, 64)
+
+		case "X-SOLO-ETAG":
+			task.ETag = unescape(prop.value)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s (%w)", prop.name, err, ErrInvalidCalendar) //nolint:errorlint
+		}
+	}
+
+	return task, nil
+}
+
+func parseTime(prop *property) (time.Time, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len(dateLayout) {
+		return time.ParseInLocation(dateLayout, prop.value, time.Local)
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		return time.Parse(utcLayout, prop.value)
+	}
+
+	loc := time.Local
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		var err error
+
+		loc, err = time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.ParseInLocation(localLayout, prop.value, loc)
+}
+
+func (c *component) write(w *bufio.Writer) error {
+	lines := []string{"BEGIN:" + c.name}
+
+	for _, prop := range c.props {
+		lines = append(lines, prop.String())
+	}
+
+	for _, line := range lines {
+		err := writeFolded(w, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range c.components {
+		err := sub.write(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFolded(w, "END:"+c.name)
+}
+
+func (p *property) String() string {
+	b := strings.Builder{}
+	b.WriteString(p.name)
+
+	for key, val := range p.params {
+		fmt.Fprintf(&b, ";%s=%s", key, val)
+	}
+
+	b.WriteString(":")
+	b.WriteString(p.value)
+
+	return b.String()
+}
+
+func writeFolded(w *bufio.Writer, line string) error {
+	first := true
+
+	for len(line) > 0 {
+		limit := maxLineLen
+		if !first {
+			// Leading space counts toward the limit
+			limit--
+		}
+
+		n := len(line)
+		if n > limit {
+			n = limit
+
+			// Don't split a UTF-8 sequence
+			for n > 1 && line[n]&0xC0 == 0x80 {
+				n--
+			}
+		}
+
+		if !first {
+			_, err := w.WriteString(" ")
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := w.WriteString(line[:n] + "\r\n")
+		if err != nil {
+			return err
+		}
+
+		line = line[n:]
+		first = false
+	}
+
+	return nil
+}
+
+func parse(r io.Reader) ([]*component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*component{}
+	stack := []*component{}
+
+	for _, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			stack = append(stack, &component{name: strings.ToUpper(prop.value)})
+
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(prop.value) {
+				return nil, fmt.Errorf("unexpected END:%s (%w)", prop.value, ErrInvalidCalendar)
+			}
+
+			done := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if len(stack) == 0 {
+				ret = append(ret, done)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, done)
+			}
+
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%s outside component (%w)", prop.name, ErrInvalidCalendar)
+			}
+
+			cur := stack[len(stack)-1]
+			cur.props = append(cur.props, prop)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unterminated %s (%w)", stack[len(stack)-1].name, ErrInvalidCalendar)
+	}
+
+	return ret, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scan := bufio.NewScanner(r)
+
+	for scan.Scan() {
+		line := strings.TrimSuffix(scan.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func parseLine(line string) (*property, error) {
+	// The first unquoted colon separates name and params from the value
+	colon := -1
+	quoted := false
+
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+
+	if colon == -1 {
+		return nil, fmt.Errorf("%s (%w)", line, ErrInvalidCalendar)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+
+	prop := &property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  line[colon+1:],
+	}
+
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+
+	return prop, nil
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
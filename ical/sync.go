@@ -0,0 +1,181 @@
+package ical
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/gopatchy/metadata"
+	"github.com/tasksolo/gosolo"
+)
+
+var todoFields = []string{"name", "complete", "after"}
+
+type ImportOpts struct {
+	// JSON file mapping UIDs from other calendar apps to the tasks created
+	// for them. Without it, every import of such a UID creates a new task.
+	StatePath string
+}
+
+type ImportResult struct {
+	Created []*gosolo.Task
+	Updated []*gosolo.Task
+}
+
+func Export(ctx context.Context, c *gosolo.Client, w io.Writer) error {
+	list, err := c.ListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return WriteCalendar(w, list)
+}
+
+func ExportFile(ctx context.Context, c *gosolo.Client, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = Export(ctx, c, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Import upserts by UID: a VTODO whose UID is an existing task ID updates
+// that task. Other UIDs (minted by other calendar apps) create a new task;
+// with ImportOpts.StatePath set, the task ID is recorded there so importing
+// the same UID again updates that task instead.
+func Import(ctx context.Context, c *gosolo.Client, r io.Reader, opts *ImportOpts) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOpts{}
+	}
+
+	list, err := ReadCalendar(r)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := loadIDs(opts.StatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := importList(ctx, c, list, ids)
+
+	if opts.StatePath != "" {
+		saveErr := saveIDs(opts.StatePath, ids)
+		if err == nil {
+			err = saveErr
+		}
+	}
+
+	return ret, err
+}
+
+func importList(ctx context.Context, c *gosolo.Client, list []*gosolo.Task, ids map[string]string) (*ImportResult, error) {
+	ret := &ImportResult{}
+
+	for _, task := range list {
+		uid := task.ID
+
+		id := uid
+		if mapped, found := ids[uid]; found {
+			id = mapped
+		}
+
+		var existing *gosolo.Task
+
+		if id != "" {
+			var err error
+
+			existing, err = c.GetTask(ctx, id, nil)
+			if err != nil {
+				return ret, err
+			}
+		}
+
+		body := *task
+		body.Metadata = metadata.Metadata{}
+
+		if existing == nil {
+			created, err := c.CreateTask(ctx, &body)
+			if err != nil {
+				return ret, err
+			}
+
+			if uid != "" && uid != created.ID {
+				ids[uid] = created.ID
+			}
+
+			ret.Created = append(ret.Created, created)
+
+			continue
+		}
+
+		// Name every mapped field, so a VTODO can also clear them (e.g.
+		// STATUS:NEEDS-ACTION reopening a completed task)
+		updated, err := c.UpdateTask(ctx, existing.ID, &body, &gosolo.UpdateOpts[gosolo.Task]{
+			Prev:   existing,
+			Fields: todoFields,
+		})
+		if err != nil {
+			return ret, err
+		}
+
+		ret.Updated = append(ret.Updated, updated)
+	}
+
+	return ret, nil
+}
+
+// loadIDs returns the UID -> task ID map at path, empty if there's no path
+// or file yet.
+func loadIDs(path string) (map[string]string, error) {
+	ids := map[string]string{}
+
+	if path == "" {
+		return ids, nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ids, nil
+
+	case err != nil:
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return ids, nil
+}
+
+func saveIDs(path string, ids map[string]string) error {
+	data, err := json.MarshalIndent(ids, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,94 @@
+package ical_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/ical"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+)
+
+func TestImportReopens(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("task", map[string]any{"id": "abc", "name": "water plants", "complete": true})
+
+	c := gosolo.NewClientDirect(srv.URL)
+
+	cal := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//test//EN",
+		"BEGIN:VTODO",
+		"UID:abc",
+		"SUMMARY:water plants",
+		"STATUS:NEEDS-ACTION",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	res, err := ical.Import(context.Background(), c, strings.NewReader(cal), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Updated) != 1 || res.Updated[0].Complete {
+		t.Fatalf("updated: %+v", res.Updated)
+	}
+
+	if obj := srv.Get("task", "abc"); obj["complete"] != false {
+		t.Errorf("after import: %v", obj)
+	}
+}
+
+func TestImportForeignUID(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	c := gosolo.NewClientDirect(srv.URL)
+	opts := &ical.ImportOpts{
+		StatePath: filepath.Join(t.TempDir(), "ical.json"),
+	}
+
+	for i, status := range []string{"NEEDS-ACTION", "COMPLETED"} {
+		cal := strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:-//test//EN",
+			"BEGIN:VTODO",
+			"UID:2f1c9e04-other-app@example.com",
+			"SUMMARY:water plants",
+			"STATUS:" + status,
+			"END:VTODO",
+			"END:VCALENDAR",
+			"",
+		}, "\r\n")
+
+		res, err := ical.Import(context.Background(), c, strings.NewReader(cal), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 && len(res.Created) != 1 {
+			t.Fatalf("first import: %+v", res)
+		}
+
+		if i == 1 && (len(res.Created) != 0 || len(res.Updated) != 1 || !res.Updated[0].Complete) {
+			t.Fatalf("second import: %+v", res)
+		}
+	}
+
+	list := srv.List("task")
+	if len(list) != 1 || list[0]["complete"] != true {
+		t.Errorf("tasks: %v", list)
+	}
+}
@@ -0,0 +1,441 @@
+// Package fakeapi is an in-memory stand-in for the Solø API, for tests.
+// It covers the REST subset the client uses: create, get, list (with eq,
+// gt, gte, lt, lte and hp filters, _sort, _limit, _offset and _after), replace,
+// merge patch and delete, with ETags, If-Match and If-None-Match. Streams
+// are not supported.
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopatchy/jsrest"
+)
+
+type Server struct {
+	*httptest.Server
+
+	// Called with each request before it is handled; a non-nil return is
+	// sent instead
+	Hook func(r *http.Request) error
+
+	objs   map[string]map[string]map[string]any
+	counts map[string]int
+	nextID int
+
+	mu sync.Mutex
+}
+
+func New() *Server {
+	srv := &Server{
+		objs:   map[string]map[string]map[string]any{},
+		counts: map[string]int{},
+	}
+
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.handle))
+
+	return srv
+}
+
+// Count returns how many requests were made with method to resource name
+func (srv *Server) Count(method, name string) int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return srv.counts[method+" "+name]
+}
+
+// Put stores obj (which must have an "id") as if created, returning it
+func (srv *Server) Put(name string, obj map[string]any) map[string]any {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return srv.store(name, obj["id"].(string), obj, 0)
+}
+
+// Get returns a copy of the stored object, or nil
+func (srv *Server) Get(name, id string) map[string]any {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	return clone(srv.objs[name][id])
+}
+
+// List returns copies of all stored objects of name, sorted by id
+func (srv *Server) List(name string) []map[string]any {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	ret := []map[string]any{}
+
+	for _, obj := range srv.objs[name] {
+		ret = append(ret, clone(obj))
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i]["id"].(string) < ret[j]["id"].(string) })
+
+	return ret
+}
+
+func (srv *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if srv.Hook != nil {
+		err := srv.Hook(r)
+		if err != nil {
+			jsrest.WriteError(w, err)
+			return
+		}
+	}
+
+	name, id, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.counts[r.Method+" "+name]++
+
+	if srv.objs[name] == nil {
+		srv.objs[name] = map[string]map[string]any{}
+	}
+
+	var (
+		ret any
+		err error
+	)
+
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		ret, err = srv.create(name, r)
+
+	case id == "" && r.Method == http.MethodGet:
+		ret, err = srv.list(name, r)
+
+	case r.Method == http.MethodGet:
+		ret, err = srv.get(name, id, r)
+
+	case r.Method == http.MethodPut || r.Method == http.MethodPatch:
+		ret, err = srv.update(name, id, r)
+
+	case r.Method == http.MethodDelete:
+		ret, err = srv.delete(name, id, r)
+
+	default:
+		err = jsrest.Errorf(jsrest.ErrMethodNotAllowed, "%s", r.Method)
+	}
+
+	if err != nil {
+		jsrest.WriteError(w, err)
+		return
+	}
+
+	if ret == nil {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ret)
+}
+
+func (srv *Server) create(name string, r *http.Request) (any, error) {
+	obj, err := readObj(r)
+	if err != nil {
+		return nil, err
+	}
+
+	srv.nextID++
+
+	return srv.store(name, fmt.Sprintf("%s%04d", name, srv.nextID), obj, 0), nil
+}
+
+func (srv *Server) get(name, id string, r *http.Request) (any, error) {
+	obj := srv.objs[name][id]
+	if obj == nil {
+		return nil, jsrest.Errorf(jsrest.ErrNotFound, "%s", id)
+	}
+
+	if r.Header.Get("If-None-Match") == fmt.Sprintf(`"%s"`, obj["etag"]) {
+		return nil, nil
+	}
+
+	return obj, nil
+}
+
+func (srv *Server) update(name, id string, r *http.Request) (any, error) {
+	old := srv.objs[name][id]
+	if old == nil {
+		return nil, jsrest.Errorf(jsrest.ErrNotFound, "%s", id)
+	}
+
+	err := checkIfMatch(old, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if r.Method == http.MethodPatch && ct != "application/json" && ct != "application/merge-patch+json" {
+		return nil, jsrest.Errorf(jsrest.ErrUnsupportedMediaType, "%s", ct)
+	}
+
+	obj, err := readObj(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Method == http.MethodPatch {
+		obj = mergePatch(clone(old), obj)
+	}
+
+	return srv.store(name, id, obj, old["generation"].(int64)), nil
+}
+
+func (srv *Server) delete(name, id string, r *http.Request) (any, error) {
+	old := srv.objs[name][id]
+	if old == nil {
+		return nil, jsrest.Errorf(jsrest.ErrNotFound, "%s", id)
+	}
+
+	err := checkIfMatch(old, r)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(srv.objs[name], id)
+
+	return map[string]any{}, nil
+}
+
+func (srv *Server) list(name string, r *http.Request) (any, error) {
+	ret := []map[string]any{}
+	query := r.URL.Query()
+
+	for _, obj := range srv.objs[name] {
+		ok, err := matches(obj, query)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			ret = append(ret, obj)
+		}
+	}
+
+	sorts := append([]string{"+id"}, query["_sort"]...)
+
+	for i := len(sorts) - 1; i >= 0; i-- {
+		field := strings.TrimLeft(sorts[i], "+-")
+		desc := strings.HasPrefix(sorts[i], "-")
+
+		sort.SliceStable(ret, func(a, b int) bool {
+			if desc {
+				return less(ret[b][field], ret[a][field])
+			}
+
+			return less(ret[a][field], ret[b][field])
+		})
+	}
+
+	if after := query.Get("_after"); after != "" {
+		for i, obj := range ret {
+			if obj["id"] == after {
+				ret = ret[i+1:]
+				break
+			}
+		}
+	}
+
+	if offset, _ := strconv.Atoi(query.Get("_offset")); offset > 0 {
+		if offset > len(ret) {
+			offset = len(ret)
+		}
+
+		ret = ret[offset:]
+	}
+
+	if limit, _ := strconv.Atoi(query.Get("_limit")); limit > 0 && limit < len(ret) {
+		ret = ret[:limit]
+	}
+
+	return ret, nil
+}
+
+func (srv *Server) store(name, id string, obj map[string]any, generation int64) map[string]any {
+	obj = clone(obj)
+	obj["id"] = id
+	obj["generation"] = generation + 1
+	obj["etag"] = fmt.Sprintf("etag:%s:%d", id, generation+1)
+
+	if srv.objs[name] == nil {
+		srv.objs[name] = map[string]map[string]any{}
+	}
+
+	srv.objs[name][id] = obj
+
+	return obj
+}
+
+func checkIfMatch(obj map[string]any, r *http.Request) error {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch != "" && ifMatch != fmt.Sprintf(`"%s"`, obj["etag"]) {
+		return jsrest.Errorf(jsrest.ErrPreconditionFailed, "etag mismatch")
+	}
+
+	return nil
+}
+
+func matches(obj map[string]any, query map[string][]string) (bool, error) {
+	for key, vals := range query {
+		path, op, found := strings.Cut(strings.TrimSuffix(key, "]"), "[")
+		if !found {
+			continue
+		}
+
+		cmp := compare(obj[path], vals[0])
+
+		var ok bool
+
+		switch op {
+		case "eq":
+			ok = cmp == 0
+		case "gt":
+			ok = cmp > 0
+		case "gte":
+			ok = cmp >= 0
+		case "lt":
+			ok = cmp < 0
+		case "lte":
+			ok = cmp <= 0
+		case "hp":
+			ok = strings.HasPrefix(fmt.Sprint(obj[path]), vals[0])
+		default:
+			return false, jsrest.Errorf(jsrest.ErrBadRequest, "unsupported op %s", op)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// compare orders a stored field value against a query string, treating a
+// missing field as the zero value like the real server's omitempty fields
+func compare(val any, str string) int {
+	switch v := val.(type) {
+	case bool:
+		return strings.Compare(strconv.FormatBool(v), str)
+
+	case float64:
+		f, _ := strconv.ParseFloat(str, 64)
+		return compareFloat(v, f)
+
+	case int64:
+		f, _ := strconv.ParseFloat(str, 64)
+		return compareFloat(float64(v), f)
+
+	case nil:
+		if str == "false" || str == "0" || str == "" {
+			return 0
+		}
+
+		return -1
+	}
+
+	s := fmt.Sprint(val)
+
+	t1, err1 := time.Parse(time.RFC3339Nano, s)
+	t2, err2 := time.Parse(time.RFC3339Nano, str)
+
+	if err1 == nil && err2 == nil {
+		switch {
+		case t1.Before(t2):
+			return -1
+		case t1.After(t2):
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(s, str)
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func less(a, b any) bool {
+	return compare(a, fmt.Sprint(b)) < 0
+}
+
+func readObj(r *http.Request) (map[string]any, error) {
+	js, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := map[string]any{}
+
+	err = json.Unmarshal(js, &obj)
+	if err != nil {
+		return nil, jsrest.Errorf(jsrest.ErrBadRequest, "%s", err)
+	}
+
+	delete(obj, "id")
+	delete(obj, "etag")
+	delete(obj, "generation")
+
+	return obj, nil
+}
+
+func mergePatch(obj, patch map[string]any) map[string]any {
+	for key, val := range patch {
+		sub, isObj := val.(map[string]any)
+		old, oldIsObj := obj[key].(map[string]any)
+
+		switch {
+		case val == nil:
+			delete(obj, key)
+
+		case isObj && oldIsObj:
+			obj[key] = mergePatch(old, sub)
+
+		default:
+			obj[key] = val
+		}
+	}
+
+	return obj
+}
+
+func clone(obj map[string]any) map[string]any {
+	if obj == nil {
+		return nil
+	}
+
+	js, _ := json.Marshal(obj)
+	ret := map[string]any{}
+	_ = json.Unmarshal(js, &ret)
+
+	// Keep generation an int64 through the round trip
+	if gen, ok := ret["generation"].(float64); ok {
+		ret["generation"] = int64(gen)
+	}
+
+	return ret
+}
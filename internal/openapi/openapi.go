@@ -0,0 +1,372 @@
+// Package openapi extracts resource types from the server's _openapi
+// document, for code generation and schema checks.
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type Resource struct {
+	// API name, as in the URL path
+	Name string
+
+	// Go type name
+	TypeName string
+
+	// Sorted by JSONName; metadata fields (id, etag, generation) are left
+	// out since they come from the embedded metadata.Metadata
+	Fields []*Field
+}
+
+type Field struct {
+	JSONName string
+	GoName   string
+	GoType   string
+
+	// OpenAPI type and format, e.g. "string" and "date-time"
+	Type   string
+	Format string
+}
+
+var (
+	ErrInvalidDocument = fmt.Errorf("invalid OpenAPI document")
+
+	metadataFields = map[string]bool{
+		"id":         true,
+		"etag":       true,
+		"generation": true,
+	}
+
+	initialisms = map[string]string{
+		"api":  "API",
+		"etag": "ETag",
+		"http": "HTTP",
+		"id":   "ID",
+		"json": "JSON",
+		"uid":  "UID",
+		"url":  "URL",
+		"uuid": "UUID",
+	}
+)
+
+// Resources returns every resource with both /{name} and /{name}/{id}
+// paths, sorted by TypeName. typeNames overrides the Go name chosen for an
+// API name.
+func Resources(doc map[string]any, typeNames map[string]string) ([]*Resource, error) {
+	paths, _ := doc["paths"].(map[string]any)
+	if paths == nil {
+		return nil, fmt.Errorf("no paths (%w)", ErrInvalidDocument)
+	}
+
+	ret := []*Resource{}
+
+	for path, item := range paths {
+		name := strings.TrimPrefix(path, "/")
+		if name == "" || strings.Contains(name, "/") || strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		objPath := findObjectPath(paths, name)
+		if objPath == "" {
+			continue
+		}
+
+		schema, schemaName := resourceSchema(doc, paths[objPath], item, name)
+		if schema == nil {
+			return nil, fmt.Errorf("%s: no schema (%w)", name, ErrInvalidDocument)
+		}
+
+		res := &Resource{
+			Name:     name,
+			TypeName: typeNames[name],
+		}
+
+		if res.TypeName == "" {
+			res.TypeName = typeName(name, schema, schemaName)
+		}
+
+		props, err := properties(doc, schema, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		for jsonName, prop := range props {
+			if metadataFields[jsonName] {
+				continue
+			}
+
+			field := &Field{
+				JSONName: jsonName,
+				GoName:   GoName(jsonName),
+			}
+
+			field.Type, _ = prop["type"].(string)
+			field.Format, _ = prop["format"].(string)
+			field.GoType = goType(doc, prop, 0)
+
+			res.Fields = append(res.Fields, field)
+		}
+
+		sort.Slice(res.Fields, func(i, j int) bool { return res.Fields[i].JSONName < res.Fields[j].JSONName })
+
+		ret = append(ret, res)
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].TypeName < ret[j].TypeName })
+
+	return ret, nil
+}
+
+// GoName converts a JSON field name (camelCase, snake_case or kebab-case)
+// to an exported Go identifier.
+func GoName(jsonName string) string {
+	words := splitWords(jsonName)
+	b := strings.Builder{}
+
+	for _, word := range words {
+		if init, found := initialisms[strings.ToLower(word)]; found {
+			b.WriteString(init)
+			continue
+		}
+
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	ret := b.String()
+
+	if ret == "" || !unicode.IsLetter([]rune(ret)[0]) {
+		ret = "X" + ret
+	}
+
+	return ret
+}
+
+func findObjectPath(paths map[string]any, name string) string {
+	for path := range paths {
+		prefix := "/" + name + "/{"
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		rest := strings.TrimPrefix(path, prefix)
+		if strings.HasSuffix(rest, "}") && !strings.Contains(rest, "/") {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// resourceSchema finds the object schema from the GET response of the
+// object path, falling back to the list path and then a component named
+// like the resource.
+func resourceSchema(doc map[string]any, objItem, listItem any, name string) (map[string]any, string) {
+	for _, item := range []any{objItem, listItem} {
+		schema := getSchema(item)
+		if schema == nil {
+			continue
+		}
+
+		if schema["type"] == "array" {
+			schema, _ = schema["items"].(map[string]any)
+		}
+
+		resolved, refName := resolve(doc, schema)
+		if resolved != nil {
+			return resolved, refName
+		}
+	}
+
+	schemas := components(doc)
+
+	for key, schema := range schemas {
+		if strings.EqualFold(key, name) {
+			m, _ := schema.(map[string]any)
+			return m, key
+		}
+	}
+
+	return nil, ""
+}
+
+func getSchema(item any) map[string]any {
+	m, _ := item.(map[string]any)
+	get, _ := m["get"].(map[string]any)
+	responses, _ := get["responses"].(map[string]any)
+	ok, _ := responses["200"].(map[string]any)
+	content, _ := ok["content"].(map[string]any)
+	js, _ := content["application/json"].(map[string]any)
+	schema, _ := js["schema"].(map[string]any)
+
+	return schema
+}
+
+func components(doc map[string]any) map[string]any {
+	comps, _ := doc["components"].(map[string]any)
+	schemas, _ := comps["schemas"].(map[string]any)
+
+	return schemas
+}
+
+// resolve follows $ref chains, returning the schema and the name of the
+// last component referenced.
+func resolve(doc map[string]any, schema map[string]any) (map[string]any, string) {
+	name := ""
+
+	for i := 0; schema != nil && i < 16; i++ {
+		ref, _ := schema["$ref"].(string)
+		if ref == "" {
+			return schema, name
+		}
+
+		name = strings.TrimPrefix(ref, "#/components/schemas/")
+		schema, _ = components(doc)[name].(map[string]any)
+	}
+
+	return nil, ""
+}
+
+// properties merges the properties of schema and any allOf members.
+func properties(doc map[string]any, schema map[string]any, depth int) (map[string]map[string]any, error) {
+	if depth > 16 {
+		return nil, fmt.Errorf("schema nested too deeply (%w)", ErrInvalidDocument)
+	}
+
+	schema, _ = resolve(doc, schema)
+	ret := map[string]map[string]any{}
+
+	all, _ := schema["allOf"].([]any)
+
+	for _, sub := range all {
+		subSchema, _ := sub.(map[string]any)
+
+		props, err := properties(doc, subSchema, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, prop := range props {
+			ret[key] = prop
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+
+	for key, prop := range props {
+		m, _ := prop.(map[string]any)
+		if m == nil {
+			return nil, fmt.Errorf("property %s: not an object (%w)", key, ErrInvalidDocument)
+		}
+
+		resolved, _ := resolve(doc, m)
+		if resolved == nil {
+			return nil, fmt.Errorf("property %s: unresolvable $ref (%w)", key, ErrInvalidDocument)
+		}
+
+		ret[key] = resolved
+	}
+
+	return ret, nil
+}
+
+func goType(doc map[string]any, schema map[string]any, depth int) string {
+	schema, _ = resolve(doc, schema)
+	if schema == nil || depth > 16 {
+		return "any"
+	}
+
+	typ, _ := schema["type"].(string)
+	format, _ := schema["format"].(string)
+
+	switch typ {
+	case "string":
+		if format == "date-time" {
+			return "time.Time"
+		}
+
+		return "string"
+
+	case "integer":
+		if format == "int32" {
+			return "int32"
+		}
+
+		return "int64"
+
+	case "number":
+		if format == "float" {
+			return "float32"
+		}
+
+		return "float64"
+
+	case "boolean":
+		return "bool"
+
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		return "[]" + goType(doc, items, depth+1)
+
+	case "object":
+		extra, _ := schema["additionalProperties"].(map[string]any)
+		if extra != nil {
+			return "map[string]" + goType(doc, extra, depth+1)
+		}
+
+		return "map[string]any"
+
+	default:
+		return "any"
+	}
+}
+
+func typeName(name string, schema map[string]any, schemaName string) string {
+	if title, _ := schema["title"].(string); title != "" {
+		return GoName(title)
+	}
+
+	// Component names that only differ in case carry the Go name, e.g.
+	// ShardServerConfig for shardserverconfig
+	if schemaName != "" && strings.EqualFold(schemaName, name) {
+		return GoName(schemaName)
+	}
+
+	return GoName(name)
+}
+
+func splitWords(s string) []string {
+	words := []string{}
+	cur := []rune{}
+
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = []rune{}
+		}
+	}
+
+	runes := []rune(s)
+
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+			flush()
+
+		case unicode.IsUpper(r) && len(cur) > 0 && (unicode.IsLower(cur[len(cur)-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+			cur = append(cur, r)
+
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			cur = append(cur, r)
+		}
+	}
+
+	flush()
+
+	return words
+}
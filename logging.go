@@ -0,0 +1,287 @@
+package gosolo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/exp/slog"
+)
+
+const redacted = "[REDACTED]"
+
+var redactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+}
+
+var redactedFields = []string{
+	"password",
+	"token",
+}
+
+type ctxKey int
+
+const (
+	attemptKey ctxKey = iota
+	operationKey
+)
+
+// SetLogger emits structured records for each request and for stream
+// lifecycle events. A nil logger disables them.
+func (c *Client) SetLogger(logger *slog.Logger) *Client {
+	c.logger = logger
+
+	if logger == nil {
+		c.rst.SetLogger(&restyStdLogger{logger: log.New(os.Stderr, "RESTY ", log.LstdFlags)})
+	} else {
+		c.rst.SetLogger(&restyLogger{logger: logger})
+	}
+
+	c.updateTransport()
+
+	return c
+}
+
+func (u User) LogValue() slog.Value {
+	u.Password = redactString(u.Password)
+	return slog.AnyValue(userNoLogValue(u))
+}
+
+func (t Token) LogValue() slog.Value {
+	t.Token = redactString(t.Token)
+	return slog.AnyValue(tokenNoLogValue(t))
+}
+
+// Strip the LogValue method to avoid recursion
+type (
+	userNoLogValue  User
+	tokenNoLogValue Token
+)
+
+func (c *Client) logWrap(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+
+		resp, err := next.RoundTrip(req)
+
+		attrs := []slog.Attr{
+			slog.String("operation", operationFromContext(req.Context())),
+			slog.String("method", req.Method),
+		}
+
+		attrs = append(attrs, c.resourceAttrs(req.URL)...)
+
+		if attempt, ok := req.Context().Value(attemptKey).(int); ok {
+			attrs = append(attrs, slog.Int("attempt", attempt))
+		}
+
+		attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+			c.logger.LogAttrs(req.Context(), slog.LevelWarn, "request failed", attrs...)
+
+			return resp, err
+		}
+
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+
+		if req.Header.Get("If-None-Match") != "" {
+			if resp.StatusCode == http.StatusNotModified {
+				attrs = append(attrs, slog.String("etag", "hit"))
+			} else {
+				attrs = append(attrs, slog.String("etag", "miss"))
+			}
+		}
+
+		level := slog.LevelDebug
+		if resp.StatusCode >= 500 {
+			level = slog.LevelWarn
+		}
+
+		c.logger.LogAttrs(req.Context(), level, "request", attrs...)
+
+		return resp, err
+	})
+}
+
+func (c *Client) resourceAttrs(u *url.URL) []slog.Attr {
+	resource, id := c.resourcePath(u)
+
+	attrs := []slog.Attr{
+		slog.String("resource", resource),
+	}
+
+	if id != "" {
+		attrs = append(attrs, slog.String("id", id))
+	}
+
+	return attrs
+}
+
+func (c *Client) resourcePath(u *url.URL) (string, string) {
+	path := u.Path
+
+	base, err := url.Parse(c.rst.BaseURL)
+	if err == nil {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+
+	parts := strings.SplitN(strings.Trim(path, "/"), "/", 2)
+
+	if len(parts) > 1 {
+		return parts[0], parts[1]
+	}
+
+	return parts[0], ""
+}
+
+func (c *Client) logStream(ctx context.Context, level slog.Level, msg, name string, attrs ...slog.Attr) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs = append([]slog.Attr{slog.String("resource", name)}, attrs...)
+
+	if attempt, ok := ctx.Value(attemptKey).(int); ok {
+		attrs = append(attrs, slog.Int("attempt", attempt))
+	}
+
+	c.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey, attempt)
+}
+
+func redactRequestLog(rl *resty.RequestLog) error {
+	redactHeaders(rl.Header)
+	rl.Body = redactBody(rl.Body)
+
+	return nil
+}
+
+func redactResponseLog(rl *resty.ResponseLog) error {
+	redactHeaders(rl.Header)
+	rl.Body = redactBody(rl.Body)
+
+	return nil
+}
+
+func redactHeaders(hdr http.Header) {
+	for _, name := range redactedHeaders {
+		if hdr.Get(name) != "" {
+			hdr.Set(name, redacted)
+		}
+	}
+}
+
+func redactBody(body string) string {
+	var obj any
+
+	err := json.Unmarshal([]byte(body), &obj)
+	if err != nil {
+		return body
+	}
+
+	if !redactValue(obj) {
+		return body
+	}
+
+	js, err := json.MarshalIndent(obj, "", "   ")
+	if err != nil {
+		return redacted
+	}
+
+	return string(js)
+}
+
+func redactValue(v any) bool {
+	changed := false
+
+	switch val := v.(type) {
+	case map[string]any:
+		for key, sub := range val {
+			if str, ok := sub.(string); ok && isRedactedField(key) && str != "" {
+				val[key] = redacted
+				changed = true
+
+				continue
+			}
+
+			if redactValue(sub) {
+				changed = true
+			}
+		}
+
+	case []any:
+		for _, sub := range val {
+			if redactValue(sub) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+func isRedactedField(key string) bool {
+	for _, field := range redactedFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+type restyLogger struct {
+	logger *slog.Logger
+}
+
+func (rl *restyLogger) Errorf(format string, v ...any) {
+	rl.logger.Error(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (rl *restyLogger) Warnf(format string, v ...any) {
+	rl.logger.Warn(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (rl *restyLogger) Debugf(format string, v ...any) {
+	rl.logger.Debug(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+// Matches resty's default logger
+type restyStdLogger struct {
+	logger *log.Logger
+}
+
+func (rl *restyStdLogger) Errorf(format string, v ...any) {
+	rl.logger.Printf("ERROR "+format, v...)
+}
+
+func (rl *restyStdLogger) Warnf(format string, v ...any) {
+	rl.logger.Printf("WARN "+format, v...)
+}
+
+func (rl *restyStdLogger) Debugf(format string, v ...any) {
+	rl.logger.Printf("DEBUG "+format, v...)
+}
@@ -0,0 +1,60 @@
+package gosolo_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+	"golang.org/x/exp/slog"
+)
+
+func TestLoggingRedacts(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := gosolo.NewClientDirect(srv.URL).
+		SetAuthToken("bearer-secret").
+		SetDebug(true).
+		SetLogger(logger)
+
+	ctx := context.Background()
+
+	user, err := c.CreateUser(ctx, &gosolo.User{Name: "Ann", Password: "user-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := c.CreateToken(ctx, &gosolo.Token{UserID: user.ID, Token: "token-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.ListUser(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("objects", "user", user, "token", token, "ptr", &gosolo.User{Password: "ptr-secret"})
+
+	out := buf.String()
+
+	for _, want := range []string{"Authorization", "[REDACTED]", "Ann", "resource=user"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+
+	for _, secret := range []string{"bearer-secret", "user-secret", "token-secret", "ptr-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q:\n%s", secret, out)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package markdown syncs a Markdown file's checklist items with Solø tasks.
+//
+// Each "- [ ] name" or "- [x] name" item is a task; "[x]" is Complete. The
+// task's short ID follows the name as an HTML comment, which doesn't show
+// up when rendered, e.g. "- [ ] buy milk <!-- solo:1a2b3c4d -->".
+//
+// Lines that aren't checklist items are kept as they are.
+package markdown
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+type Document struct {
+	Lines []*Line
+}
+
+// Line is either an Item or Raw text.
+type Line struct {
+	Raw  string
+	Item *Item
+}
+
+type Item struct {
+	// Leading whitespace and bullet, e.g. "  - "
+	Prefix string
+
+	Name    string
+	Checked bool
+	ShortID string
+}
+
+var itemRE = regexp.MustCompile(`^(\s*[-*+]\s+)\[([ xX])\]\s+(.*?)\s*(?:<!--\s*solo:(\S+)\s*-->)?\s*$`)
+
+func Parse(r io.Reader) (*Document, error) {
+	doc := &Document{}
+	scan := bufio.NewScanner(r)
+
+	for scan.Scan() {
+		doc.Lines = append(doc.Lines, parseLine(scan.Text()))
+	}
+
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func (doc *Document) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, line := range doc.Lines {
+		_, err := bw.WriteString(line.String() + "\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (doc *Document) String() string {
+	b := strings.Builder{}
+	_ = doc.Write(&b)
+
+	return b.String()
+}
+
+func (doc *Document) Items() []*Item {
+	ret := []*Item{}
+
+	for _, line := range doc.Lines {
+		if line.Item != nil {
+			ret = append(ret, line.Item)
+		}
+	}
+
+	return ret
+}
+
+// Append adds item after the last existing item, or at the end if there
+// are none.
+func (doc *Document) Append(item *Item) {
+	line := &Line{Item: item}
+
+	for i := len(doc.Lines) - 1; i >= 0; i-- {
+		if doc.Lines[i].Item != nil {
+			doc.Lines = append(doc.Lines[:i+1], append([]*Line{line}, doc.Lines[i+1:]...)...)
+			return
+		}
+	}
+
+	doc.Lines = append(doc.Lines, line)
+}
+
+// Remove drops the line holding item.
+func (doc *Document) Remove(item *Item) {
+	for i, line := range doc.Lines {
+		if line.Item == item {
+			doc.Lines = append(doc.Lines[:i], doc.Lines[i+1:]...)
+			return
+		}
+	}
+}
+
+func (line *Line) String() string {
+	if line.Item == nil {
+		return line.Raw
+	}
+
+	return line.Item.String()
+}
+
+func (item *Item) String() string {
+	prefix := item.Prefix
+	if prefix == "" {
+		prefix = "- "
+	}
+
+	box := "[ ]"
+	if item.Checked {
+		box = "[x]"
+	}
+
+	s := prefix + box + " " + item.Name
+
+	if item.ShortID != "" {
+		s += " <!-- solo:" + item.ShortID + " -->"
+	}
+
+	return s
+}
+
+func parseLine(s string) *Line {
+	match := itemRE.FindStringSubmatch(s)
+	if match == nil || match[3] == "" {
+		return &Line{Raw: s}
+	}
+
+	return &Line{
+		Item: &Item{
+			Prefix:  match[1],
+			Name:    match[3],
+			Checked: match[2] != " ",
+			ShortID: match[4],
+		},
+	}
+}
@@ -0,0 +1,387 @@
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+type Options struct {
+	// Minimum characters of task ID written to the file; more are used
+	// where needed to keep them unique. Defaults to 8.
+	ShortIDLen int
+
+	// How often Run checks the file for edits; defaults to 2s
+	PollInterval time.Duration
+
+	// Where the state of the last sync is kept, so that after a restart
+	// deletions on either side aren't undone. Defaults to ".<name>.solo"
+	// beside the file.
+	StatePath string
+}
+
+// Syncer keeps a Markdown file and the task list in step.
+//
+// It remembers what each item looked like after the last sync (in
+// Options.StatePath, so across restarts too), so it can tell which side
+// changed: an item edited in the file is pushed to the
+// server, otherwise the server's version is written back. If both changed,
+// the file wins. Deleting a line deletes its task, and tasks deleted on the
+// server drop their line.
+type Syncer struct {
+	client *gosolo.Client
+	path   string
+	opts   *Options
+
+	base    map[string]*Item // task ID -> item as of last sync; nil until loaded
+	modTime time.Time
+}
+
+var ErrAmbiguousShortID = fmt.Errorf("ambiguous short ID")
+
+const (
+	defaultShortIDLen   = 8
+	defaultPollInterval = 2 * time.Second
+)
+
+func NewSyncer(c *gosolo.Client, path string, opts *Options) *Syncer {
+	ret := &Syncer{
+		client: c,
+		path:   path,
+		opts:   &Options{},
+	}
+
+	if opts != nil {
+		*ret.opts = *opts
+	}
+
+	if ret.opts.ShortIDLen <= 0 {
+		ret.opts.ShortIDLen = defaultShortIDLen
+	}
+
+	if ret.opts.PollInterval <= 0 {
+		ret.opts.PollInterval = defaultPollInterval
+	}
+
+	if ret.opts.StatePath == "" {
+		ret.opts.StatePath = filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".solo")
+	}
+
+	return ret
+}
+
+// SyncOnce fetches the task list and syncs the file with it.
+func (s *Syncer) SyncOnce(ctx context.Context) error {
+	list, err := s.client.ListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.sync(ctx, list)
+}
+
+// Run syncs on every StreamListTask update and whenever the file changes,
+// until ctx is done.
+func (s *Syncer) Run(ctx context.Context) error {
+	stream, err := s.client.StreamListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer stream.Close()
+
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case list, ok := <-stream.Chan():
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				return stream.Error()
+			}
+
+			err = s.sync(ctx, list)
+			if err != nil {
+				return err
+			}
+
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil || info.ModTime().Equal(s.modTime) {
+				continue
+			}
+
+			err = s.SyncOnce(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Syncer) sync(ctx context.Context, list []*gosolo.Task) error {
+	if s.base == nil {
+		err := s.loadBase()
+		if err != nil {
+			return err
+		}
+	}
+
+	doc, orig, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	byID := map[string]*gosolo.Task{}
+
+	for _, task := range list {
+		byID[task.ID] = task
+	}
+
+	itemIDs := map[*Item]string{}
+
+	for _, item := range doc.Items() {
+		id := ""
+
+		if item.ShortID != "" {
+			id, err = s.resolve(item.ShortID, byID)
+			if err != nil {
+				return err
+			}
+		}
+
+		task := byID[id]
+
+		switch {
+		case task == nil && s.base[id] != nil:
+			// Deleted on the server
+			doc.Remove(item)
+			continue
+
+		case task == nil:
+			task, err = s.client.CreateTask(ctx, &gosolo.Task{
+				Name:     item.Name,
+				Complete: item.Checked,
+			})
+			if err != nil {
+				return err
+			}
+
+		case s.fileChanged(id, item) && (item.Name != task.Name || item.Checked != task.Complete):
+			task, err = s.client.UpdateTask(ctx, task.ID, &gosolo.Task{
+				Name:     item.Name,
+				Complete: item.Checked,
+			}, &gosolo.UpdateOpts[gosolo.Task]{
+				Prev:   task,
+				Fields: []string{"name", "complete"},
+			})
+			if err != nil {
+				return err
+			}
+
+		default:
+			item.Name = task.Name
+			item.Checked = task.Complete
+		}
+
+		itemIDs[item] = task.ID
+	}
+
+	inFile := map[string]bool{}
+
+	for _, id := range itemIDs {
+		inFile[id] = true
+	}
+
+	for _, task := range list {
+		if inFile[task.ID] {
+			continue
+		}
+
+		if s.base[task.ID] != nil {
+			// Line deleted from the file
+			err = s.client.DeleteTask(ctx, task.ID, &gosolo.UpdateOpts[gosolo.Task]{Prev: task})
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		item := &Item{
+			Name:    task.Name,
+			Checked: task.Complete,
+		}
+
+		doc.Append(item)
+		itemIDs[item] = task.ID
+	}
+
+	items := []*Item{}
+	ids := []string{}
+
+	for item, id := range itemIDs {
+		items = append(items, item)
+		ids = append(ids, id)
+	}
+
+	shortIDs := gosolo.UniquePrefixes(ids, s.opts.ShortIDLen)
+	s.base = map[string]*Item{}
+
+	for i, item := range items {
+		item.ShortID = shortIDs[i]
+		s.base[ids[i]] = copyItem(item)
+	}
+
+	err = s.write(doc, orig)
+	if err != nil {
+		return err
+	}
+
+	return s.saveBase()
+}
+
+func (s *Syncer) fileChanged(id string, item *Item) bool {
+	prev := s.base[id]
+	if prev == nil {
+		// First sync, only trust a ticked box
+		return item.Checked
+	}
+
+	return item.Name != prev.Name || item.Checked != prev.Checked
+}
+
+func (s *Syncer) resolve(shortID string, byID map[string]*gosolo.Task) (string, error) {
+	// Tasks created since the last sync may share the prefix we wrote
+	for id, item := range s.base {
+		if item.ShortID == shortID {
+			return id, nil
+		}
+	}
+
+	match := ""
+
+	for id := range byID {
+		if !strings.HasPrefix(id, shortID) {
+			continue
+		}
+
+		if match != "" {
+			return "", fmt.Errorf("%s (%w)", shortID, ErrAmbiguousShortID)
+		}
+
+		match = id
+	}
+
+	return match, nil
+}
+
+func (s *Syncer) read() (*Document, []byte, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Document{}, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, data, nil
+}
+
+func (s *Syncer) write(doc *Document, orig []byte) error {
+	data := []byte(doc.String())
+
+	if !bytes.Equal(data, orig) {
+		tmp := s.path + ".tmp"
+
+		err := os.WriteFile(tmp, data, 0o644) //nolint:gosec
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(tmp, s.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Nothing to write and no file yet
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	s.modTime = info.ModTime()
+
+	return nil
+}
+
+func (s *Syncer) loadBase() error {
+	base := map[string]*Item{}
+
+	data, err := os.ReadFile(s.opts.StatePath)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+
+	case err != nil:
+		return err
+
+	default:
+		err = json.Unmarshal(data, &base)
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.opts.StatePath, err)
+		}
+	}
+
+	s.base = base
+
+	return nil
+}
+
+func (s *Syncer) saveBase() error {
+	data, err := json.MarshalIndent(s.base, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.opts.StatePath + ".tmp"
+
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.opts.StatePath)
+}
+
+func copyItem(item *Item) *Item {
+	ret := *item
+	return &ret
+}
@@ -0,0 +1,81 @@
+package markdown_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+	"github.com/tasksolo/gosolo/markdown"
+)
+
+func TestSyncStateSurvivesRestart(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("task", map[string]any{"id": "aaaaaaaaaaaa", "name": "deleted on server"})
+	srv.Put("task", map[string]any{"id": "bbbbbbbbbbbb", "name": "deleted in file"})
+	srv.Put("task", map[string]any{"id": "cccccccccccc", "name": "kept"})
+
+	ctx := context.Background()
+	c := gosolo.NewClientDirect(srv.URL)
+	path := filepath.Join(t.TempDir(), "todo.md")
+
+	err := markdown.NewSyncer(c, path, nil).SyncOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// While no syncer runs, delete one task on each side
+	err = c.DeleteTask(ctx, "aaaaaaaaaaaa", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if !strings.Contains(line, "deleted in file") {
+			lines = append(lines, line)
+		}
+	}
+
+	err = os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh syncer, as after a restart
+	err = markdown.NewSyncer(c, path, nil).SyncOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := srv.Count(http.MethodPost, "task"); n != 0 {
+		t.Errorf("%d tasks created", n)
+	}
+
+	if list := srv.List("task"); len(list) != 1 || list[0]["id"] != "cccccccccccc" {
+		t.Errorf("tasks after restart: %v", list)
+	}
+
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(data), "deleted") || !strings.Contains(string(data), "kept") {
+		t.Errorf("file after restart:\n%s", data)
+	}
+}
@@ -0,0 +1,51 @@
+package gosolo
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the transport used for every request, unary and stream
+// alike. Middleware sees the final request after auth and tracing headers are
+// set, and sits inside the circuit breaker, so injected failures count.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Use appends middleware to the chain. The first middleware added is the
+// outermost.
+func (c *Client) Use(mw ...Middleware) *Client {
+	c.middleware = append(c.middleware, mw...)
+	c.updateTransport()
+
+	return c
+}
+
+func (c *Client) updateTransport() {
+	var rt http.RoundTripper = c.transport
+
+	if c.cassette != nil {
+		rt = c.cassette.wrap(rt)
+	}
+
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+
+	if c.breakers != nil {
+		rt = c.breakers.wrap(rt)
+	}
+
+	if c.logger != nil {
+		rt = c.logWrap(rt)
+	}
+
+	if c.telemetry != nil {
+		rt = c.telemetryWrap(rt)
+	}
+
+	c.rst.SetTransport(rt)
+}
@@ -0,0 +1,126 @@
+package gosolo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Stream-Format", "full")
+			fmt.Fprint(w, "event: list\ndata: [{\"id\":\"k1\"}]\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"t1"}`))
+	}))
+	defer srv.Close()
+
+	mu := sync.Mutex{}
+	calls := []string{}
+	injected := atomic.Int32{}
+	fail := atomic.Bool{}
+
+	record := func(name string) gosolo.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return gosolo.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				calls = append(calls, fmt.Sprintf("%s>%s", name, req.Header.Get("Authorization")))
+				mu.Unlock()
+
+				if name == "inner" && fail.Load() {
+					injected.Add(1)
+					return nil, errors.New("injected")
+				}
+
+				resp, err := next.RoundTrip(req)
+
+				mu.Lock()
+				calls = append(calls, "<"+name)
+				mu.Unlock()
+
+				return resp, err
+			})
+		}
+	}
+
+	c := gosolo.NewClientDirect(srv.URL).
+		SetAuthToken("tok").
+		SetCircuitBreaker(&gosolo.BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}).
+		Use(record("outer")).
+		Use(record("inner"))
+
+	ctx := context.Background()
+
+	takeCalls := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		ret := fmt.Sprint(calls)
+		calls = []string{}
+
+		return ret
+	}
+
+	want := "[outer>Bearer tok inner>Bearer tok <inner <outer]"
+
+	_, err := c.GetTask(ctx, "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := takeCalls(); got != want {
+		t.Errorf("unary: %s, want %s", got, want)
+	}
+
+	stream, err := c.StreamListToken(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list := stream.Read(); len(list) != 1 {
+		t.Fatalf("stream: %v", list)
+	}
+
+	stream.Close()
+
+	if got := takeCalls(); got != want {
+		t.Errorf("stream: %s, want %s", got, want)
+	}
+
+	// Injected failures count toward the breaker, which then stops
+	// requests before they reach any middleware
+	fail.Store(true)
+
+	for i := 0; i < 3; i++ {
+		_, err = c.GetTask(ctx, "t1", nil)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	if !errors.Is(err, gosolo.ErrCircuitOpen) {
+		t.Errorf("got %v, want ErrCircuitOpen", err)
+	}
+
+	if got := injected.Load(); got != 2 {
+		t.Errorf("%d injected failures, want 2", got)
+	}
+}
@@ -0,0 +1,381 @@
+package gosolo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gopatchy/jsrest"
+	"github.com/gopatchy/metadata"
+)
+
+// Patch is a JSON Merge Patch (RFC 7396) body. Each key sets that field,
+// including to a zero value; a nil value clears it.
+type Patch map[string]any
+
+// JSONPatchOp is one RFC 6902 operation.
+type JSONPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+var metadataKeys = map[string]bool{
+	"id":         true,
+	"etag":       true,
+	"generation": true,
+}
+
+var ErrUnknownField = fmt.Errorf("unknown field")
+
+func (p Patch) Set(field string, val any) Patch {
+	p[field] = val
+	return p
+}
+
+func (p Patch) Clear(field string) Patch {
+	p[field] = nil
+	return p
+}
+
+// PatchFields builds a Patch holding exactly the named JSON fields of obj,
+// whether or not they're zero.
+func PatchFields[T any](obj *T, fields ...string) (Patch, error) {
+	ret := Patch{}
+	v := reflect.ValueOf(obj).Elem()
+
+	for _, field := range fields {
+		fv, found := fieldByJSONName(v, field)
+		if !found {
+			return nil, fmt.Errorf("%s (%w)", field, ErrUnknownField)
+		}
+
+		js, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		ret[field] = json.RawMessage(js)
+	}
+
+	return ret, nil
+}
+
+// PatchName sends patch as a merge patch, so unlike UpdateName it can set
+// fields to zero values or clear them.
+func PatchName[T any](ctx context.Context, c *Client, name, id string, patch Patch, opts *UpdateOpts[T]) (*T, error) {
+	ctx = withOperation(ctx, "PatchName")
+
+	updated := new(T)
+
+	r := c.rst.R().
+		SetContext(ctx).
+		SetPathParam("name", name).
+		SetPathParam("id", id).
+		SetHeader("Content-Type", mergePatchType).
+		SetBody(patch).
+		SetResult(updated)
+
+	opts.apply(r)
+
+	resp, err := r.Patch("{name}/{id}")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsError() {
+		return nil, jsrest.ReadError(resp)
+	}
+
+	return updated, nil
+}
+
+// fieldByJSONName finds the field of struct v encoded as name, looking
+// through embedded structs.
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		tagName, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && tagName == "" && field.Type.Kind() == reflect.Struct {
+			fv, found := fieldByJSONName(v.Field(i), name)
+			if found {
+				return fv, true
+			}
+
+			continue
+		}
+
+		if tagName == "" {
+			tagName = field.Name
+		}
+
+		if tagName == name && field.IsExported() {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// DiffUpdateName sends the changes from prev to next as a JSON Patch (RFC
+// 6902), conditional on prev's ETag. If the server doesn't take JSON
+// Patch, it sends the same changes as a merge patch instead. After a 415,
+// it keeps doing so for this Client; a 400 that blames the media type only
+// falls back for that call.
+func DiffUpdateName[T any](ctx context.Context, c *Client, name string, prev, next *T) (*T, error) {
+	ctx = withOperation(ctx, "DiffUpdateName")
+
+	id := metadata.GetMetadata(prev).ID
+	opts := &UpdateOpts[T]{Prev: prev}
+
+	if !c.jsonPatchRejected.Load() {
+		ops, err := DiffJSONPatch(prev, next)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ops) == 0 {
+			return prev, nil
+		}
+
+		updated := new(T)
+
+		r := c.rst.R().
+			SetContext(ctx).
+			SetPathParam("name", name).
+			SetPathParam("id", id).
+			SetHeader("Content-Type", jsonPatchType).
+			SetBody(ops).
+			SetResult(updated)
+
+		opts.apply(r)
+
+		resp, err := r.Patch("{name}/{id}")
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode() == http.StatusUnsupportedMediaType:
+			c.jsonPatchRejected.Store(true)
+
+		case resp.StatusCode() == http.StatusBadRequest:
+			err = jsrest.ReadError(resp)
+
+			// Some servers reject the media type as a bad request; fall back
+			// for this call only, since a 400 may also mean a bad patch
+			if !isMediaTypeError(err) {
+				return nil, err
+			}
+
+		case resp.IsError():
+			return nil, jsrest.ReadError(resp)
+
+		default:
+			return updated, nil
+		}
+	}
+
+	patch, err := DiffMergePatch(prev, next)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(patch) == 0 {
+		return prev, nil
+	}
+
+	return PatchName[T](ctx, c, name, id, patch, opts)
+}
+
+// isMediaTypeError reports whether a 400 error names the request's media
+// type as the problem
+func isMediaTypeError(err error) bool {
+	msg := strings.ToLower(err.Error())
+
+	for _, word := range []string{"content-type", "content type", "media type", jsonPatchType} {
+		if strings.Contains(msg, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DiffJSONPatch returns the JSON Patch that turns prev into next, ignoring
+// metadata. Arrays that differ are replaced whole.
+func DiffJSONPatch[T any](prev, next *T) ([]*JSONPatchOp, error) {
+	from, to, err := diffObjects(prev, next)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*JSONPatchOp{}
+
+	err = diffJSON("", from, to, &ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// DiffMergePatch returns the merge patch that turns prev into next,
+// ignoring metadata.
+func DiffMergePatch[T any](prev, next *T) (Patch, error) {
+	from, to, err := diffObjects(prev, next)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeDiff(from, to), nil
+}
+
+func diffObjects(prev, next any) (map[string]any, map[string]any, error) {
+	ret := []map[string]any{}
+
+	for _, obj := range []any{prev, next} {
+		js, err := json.Marshal(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		m := map[string]any{}
+
+		err = json.Unmarshal(js, &m)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for key := range metadataKeys {
+			delete(m, key)
+		}
+
+		ret = append(ret, m)
+	}
+
+	return ret[0], ret[1], nil
+}
+
+func diffJSON(path string, from, to any, ops *[]*JSONPatchOp) error {
+	fromObj, fromIsObj := from.(map[string]any)
+	toObj, toIsObj := to.(map[string]any)
+
+	if !fromIsObj || !toIsObj {
+		if reflect.DeepEqual(from, to) {
+			return nil
+		}
+
+		return appendOp(ops, "replace", path, to)
+	}
+
+	for _, key := range sortedKeys(fromObj) {
+		if _, found := toObj[key]; !found {
+			*ops = append(*ops, &JSONPatchOp{Op: "remove", Path: path + "/" + escapePointer(key)})
+		}
+	}
+
+	for _, key := range sortedKeys(toObj) {
+		sub := path + "/" + escapePointer(key)
+
+		fromVal, found := fromObj[key]
+		if !found {
+			err := appendOp(ops, "add", sub, toObj[key])
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := diffJSON(sub, fromVal, toObj[key], ops)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mergeDiff(from, to map[string]any) Patch {
+	ret := Patch{}
+
+	for key := range from {
+		if _, found := to[key]; !found {
+			ret[key] = nil
+		}
+	}
+
+	for key, toVal := range to {
+		fromVal, found := from[key]
+
+		switch {
+		case !found:
+			ret[key] = toVal
+
+		case reflect.DeepEqual(fromVal, toVal):
+
+		default:
+			fromObj, fromIsObj := fromVal.(map[string]any)
+			toObj, toIsObj := toVal.(map[string]any)
+
+			if fromIsObj && toIsObj {
+				ret[key] = mergeDiff(fromObj, toObj)
+			} else {
+				ret[key] = toVal
+			}
+		}
+	}
+
+	return ret
+}
+
+func appendOp(ops *[]*JSONPatchOp, op, path string, val any) error {
+	js, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	*ops = append(*ops, &JSONPatchOp{
+		Op:    op,
+		Path:  path,
+		Value: js,
+	})
+
+	return nil
+}
+
+// escapePointer escapes a key for a JSON Pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+func sortedKeys(m map[string]any) []string {
+	ret := make([]string, 0, len(m))
+
+	for key := range m {
+		ret = append(ret, key)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
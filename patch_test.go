@@ -0,0 +1,188 @@
+package gosolo_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gopatchy/jsrest"
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+)
+
+func TestDiffUpdateFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+
+		// Response to a JSON Patch, or nil for the fake's own 415
+		reject error
+
+		wantErr bool
+
+		// JSON Patch attempts over two updates
+		wantAttempts int32
+	}{
+		{
+			name:         "415 sticks",
+			wantAttempts: 1,
+		},
+		{
+			name:         "400 media type falls back per call",
+			reject:       jsrest.Errorf(jsrest.ErrBadRequest, "unsupported Content-Type: application/json-patch+json"),
+			wantAttempts: 2,
+		},
+		{
+			name:         "other 400 fails",
+			reject:       jsrest.Errorf(jsrest.ErrBadRequest, "name too long"),
+			wantErr:      true,
+			wantAttempts: 2,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := fakeapi.New()
+			defer srv.Close()
+
+			attempts := atomic.Int32{}
+
+			srv.Hook = func(r *http.Request) error {
+				if r.Header.Get("Content-Type") != "application/json-patch+json" {
+					return nil
+				}
+
+				attempts.Add(1)
+
+				return test.reject
+			}
+
+			srv.Put("task", map[string]any{"id": "t1", "name": "A"})
+
+			ctx := context.Background()
+			c := gosolo.NewClientDirect(srv.URL)
+
+			for _, name := range []string{"B", "C"} {
+				prev, err := c.GetTask(ctx, "t1", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				next := *prev
+				next.Name = name
+
+				updated, err := c.DiffUpdateTask(ctx, prev, &next)
+
+				switch {
+				case test.wantErr:
+					if err == nil {
+						t.Fatal("expected error")
+					}
+
+				case err != nil:
+					t.Fatal(err)
+
+				case updated.Name != name:
+					t.Errorf("got name %q, want %q", updated.Name, name)
+				}
+			}
+
+			if got := attempts.Load(); got != test.wantAttempts {
+				t.Errorf("%d JSON Patch attempts, want %d", got, test.wantAttempts)
+			}
+
+			if test.wantErr && srv.Get("task", "t1")["name"] != "A" {
+				t.Errorf("fell back after a bad request: %v", srv.Get("task", "t1"))
+			}
+		})
+	}
+}
+
+func TestPatchClearAndFields(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("task", map[string]any{"id": "abc", "name": "water plants", "complete": true, "after": "2026-05-01T09:00:00Z"})
+
+	bodies := make(chan string, 2)
+
+	srv.Hook = func(r *http.Request) error {
+		if r.Method != http.MethodPatch {
+			return nil
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != "application/merge-patch+json" {
+			t.Errorf("Content-Type: %s", ct)
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		bodies <- string(bytes.TrimSpace(body))
+
+		return nil
+	}
+
+	c := gosolo.NewClientDirect(srv.URL)
+
+	_, err := c.PatchTask(ctx, "abc", gosolo.Patch{}.Clear("after"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body := <-bodies; body != `{"after":null}` {
+		t.Errorf("Clear sent %s", body)
+	}
+
+	if obj := srv.Get("task", "abc"); obj["after"] != nil || obj["name"] != "water plants" {
+		t.Errorf("after Clear: %v", obj)
+	}
+
+	// complete=false is omitted from a plain update, but Fields names it
+	task, err := c.UpdateTask(ctx, "abc", &gosolo.Task{Complete: false}, &gosolo.UpdateOpts[gosolo.Task]{
+		Fields: []string{"complete"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body := <-bodies; body != `{"complete":false}` {
+		t.Errorf("Fields sent %s", body)
+	}
+
+	if obj := srv.Get("task", "abc"); task.Complete || obj["complete"] != false || obj["name"] != "water plants" {
+		t.Errorf("after Fields: %v", obj)
+	}
+
+	fields := &gosolo.UpdateOpts[gosolo.Task]{Fields: []string{"complete"}}
+
+	_, err = c.ReplaceTask(ctx, "abc", task, fields)
+	if !errors.Is(err, gosolo.ErrFieldsUnsupported) {
+		t.Errorf("ReplaceTask with Fields: %v", err)
+	}
+
+	err = c.DeleteTask(ctx, "abc", fields)
+	if !errors.Is(err, gosolo.ErrFieldsUnsupported) {
+		t.Errorf("DeleteTask with Fields: %v", err)
+	}
+
+	if srv.Get("task", "abc") == nil {
+		t.Error("DeleteTask with Fields deleted")
+	}
+}
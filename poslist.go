@@ -0,0 +1,157 @@
+package gosolo
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// posList is a list with O(log n) insert and delete by position, kept as an
+// implicit treap (ordered by position, heap-ordered by random priority).
+type posList[T any] struct {
+	root *posNode[T]
+}
+
+type posNode[T any] struct {
+	val      *T
+	priority uint32
+	size     int
+
+	left  *posNode[T]
+	right *posNode[T]
+}
+
+var ErrInvalidPosition = fmt.Errorf("invalid position")
+
+func newPosList[T any](list []*T) *posList[T] {
+	pl := &posList[T]{}
+
+	for _, obj := range list {
+		pl.root = mergeNodes(pl.root, newPosNode(obj))
+	}
+
+	return pl
+}
+
+func newPosNode[T any](val *T) *posNode[T] {
+	return &posNode[T]{
+		val:      val,
+		priority: rand.Uint32(), //nolint:gosec
+		size:     1,
+	}
+}
+
+func (pl *posList[T]) Len() int {
+	return pl.root.len()
+}
+
+func (pl *posList[T]) Insert(pos int, val *T) error {
+	if pos < 0 || pos > pl.Len() {
+		return fmt.Errorf("insert at %d of %d (%w)", pos, pl.Len(), ErrInvalidPosition)
+	}
+
+	left, right := splitNodes(pl.root, pos)
+	pl.root = mergeNodes(mergeNodes(left, newPosNode(val)), right)
+
+	return nil
+}
+
+func (pl *posList[T]) Delete(pos int) error {
+	if pos < 0 || pos >= pl.Len() {
+		return fmt.Errorf("delete at %d of %d (%w)", pos, pl.Len(), ErrInvalidPosition)
+	}
+
+	left, right := splitNodes(pl.root, pos)
+	_, right = splitNodes(right, 1)
+	pl.root = mergeNodes(left, right)
+
+	return nil
+}
+
+// Slice returns the list in order, in a new slice.
+func (pl *posList[T]) Slice() []*T {
+	ret := make([]*T, 0, pl.Len())
+	stack := []*posNode[T]{}
+
+	for node := pl.root; node != nil || len(stack) > 0; node = node.right {
+		for ; node != nil; node = node.left {
+			stack = append(stack, node)
+		}
+
+		node = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		ret = append(ret, node.val)
+	}
+
+	return ret
+}
+
+// snapshot returns the list to emit, carrying etag. Only the slice and the
+// first object (which holds ListETag) are copied; all other objects are
+// shared with pl and with earlier snapshots, so they must never be modified
+// once decoded.
+func (pl *posList[T]) snapshot(etag string) []*T {
+	ret := pl.Slice()
+
+	if len(ret) > 0 {
+		first := *ret[0]
+		ret[0] = &first
+		setListETag(ret, etag)
+	}
+
+	return ret
+}
+
+func (node *posNode[T]) len() int {
+	if node == nil {
+		return 0
+	}
+
+	return node.size
+}
+
+func (node *posNode[T]) update() {
+	node.size = node.left.len() + 1 + node.right.len()
+}
+
+// splitNodes splits node into the first pos entries and the rest
+func splitNodes[T any](node *posNode[T], pos int) (*posNode[T], *posNode[T]) {
+	if node == nil {
+		return nil, nil
+	}
+
+	if node.left.len() < pos {
+		left, right := splitNodes(node.right, pos-node.left.len()-1)
+		node.right = left
+		node.update()
+
+		return node, right
+	}
+
+	left, right := splitNodes(node.left, pos)
+	node.left = right
+	node.update()
+
+	return left, node
+}
+
+func mergeNodes[T any](left, right *posNode[T]) *posNode[T] {
+	switch {
+	case left == nil:
+		return right
+
+	case right == nil:
+		return left
+
+	case left.priority > right.priority:
+		left.right = mergeNodes(left.right, right)
+		left.update()
+
+		return left
+
+	default:
+		right.left = mergeNodes(left, right.left)
+		right.update()
+
+		return right
+	}
+}
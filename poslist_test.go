@@ -0,0 +1,206 @@
+package gosolo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gopatchy/metadata"
+)
+
+func benchTasks(n int) []*Task {
+	ret := make([]*Task, 0, n)
+
+	for i := 0; i < n; i++ {
+		ret = append(ret, &Task{
+			Metadata: metadata.Metadata{
+				ID:         fmt.Sprintf("task%06d", i),
+				ETag:       fmt.Sprintf("etag:%06d", i),
+				Generation: 1,
+			},
+			UserID: "user1",
+			Name:   fmt.Sprintf("task number %d", i),
+			After:  time.Date(2026, 1, 1, 0, 0, i, 0, time.UTC),
+		})
+	}
+
+	return ret
+}
+
+// jsonClone is how ListStream copied each diff-mode list before objects
+// were shared between snapshots
+func jsonClone[T any](list []*T) ([]*T, error) {
+	js, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*T{}
+
+	err = json.Unmarshal(js, &ret)
+	if err != nil {
+		return nil, err
+	}
+
+	setListETag(ret, getListETag(list))
+
+	return ret, nil
+}
+
+func TestSnapshotShares(t *testing.T) {
+	t.Parallel()
+
+	list := benchTasks(3)
+	pl := newPosList(list)
+
+	s1 := pl.snapshot(`"sync1"`)
+	s2 := pl.snapshot(`"sync2"`)
+
+	if s1[0] == list[0] || s1[0] == s2[0] {
+		t.Error("first object shared, so ListETag would leak between snapshots")
+	}
+
+	if s1[0].ListETag != `"sync1"` || s2[0].ListETag != `"sync2"` || list[0].ListETag != "" {
+		t.Errorf("ListETag: %q %q %q", s1[0].ListETag, s2[0].ListETag, list[0].ListETag)
+	}
+
+	for i := 1; i < len(list); i++ {
+		if s1[i] != list[i] || s2[i] != list[i] {
+			t.Errorf("object %d copied", i)
+		}
+	}
+}
+
+func BenchmarkSnapshot(b *testing.B) {
+	list := benchTasks(10000)
+	pl := newPosList(list)
+
+	b.Run("jsonClone", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, err := jsonClone(list)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("shared", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			pl.snapshot(`"sync1"`)
+		}
+	})
+}
+
+func TestPosListMatchesSlice(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec
+	vals := make([]int, 5000)
+
+	for i := range vals {
+		vals[i] = i
+	}
+
+	pl := newPosList([]*int{&vals[0], &vals[1]})
+	ref := []*int{&vals[0], &vals[1]}
+
+	for i := 2; i < len(vals); i++ {
+		// Positions run one past each end to cover the errors
+		pos := rng.Intn(len(ref)+3) - 1
+
+		if rng.Intn(3) == 0 {
+			err := pl.Delete(pos)
+
+			if pos < 0 || pos >= len(ref) {
+				if !errors.Is(err, ErrInvalidPosition) {
+					t.Fatalf("delete at %d of %d: %v", pos, len(ref), err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				ref = append(ref[:pos], ref[pos+1:]...)
+			}
+		} else {
+			err := pl.Insert(pos, &vals[i])
+
+			if pos < 0 || pos > len(ref) {
+				if !errors.Is(err, ErrInvalidPosition) {
+					t.Fatalf("insert at %d of %d: %v", pos, len(ref), err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				ref = append(ref[:pos], append([]*int{&vals[i]}, ref[pos:]...)...)
+			}
+		}
+
+		if pl.Len() != len(ref) {
+			t.Fatalf("step %d: len %d, want %d", i, pl.Len(), len(ref))
+		}
+
+		// Comparing every step is quadratic; every 97th still catches drift
+		if i%97 != 0 && i != len(vals)-1 {
+			continue
+		}
+
+		got := pl.Slice()
+
+		for j := range ref {
+			if got[j] != ref[j] {
+				t.Fatalf("step %d: position %d is %d, want %d", i, j, *got[j], *ref[j])
+			}
+		}
+	}
+}
+
+// BenchmarkInitialSync inserts 100k objects at random positions, as the add
+// events of a diff stream's first sync can
+func BenchmarkInitialSync(b *testing.B) {
+	const n = 100000
+
+	tasks := benchTasks(n)
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec
+	positions := make([]int, n)
+
+	for i := range positions {
+		positions[i] = rng.Intn(i + 1)
+	}
+
+	b.Run("slice", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			list := []*Task{}
+
+			for j, pos := range positions {
+				list = append(list, nil)
+				copy(list[pos+1:], list[pos:])
+				list[pos] = tasks[j]
+			}
+		}
+	})
+
+	b.Run("posList", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			list := newPosList[Task](nil)
+
+			for j, pos := range positions {
+				err := list.Insert(pos, tasks[j])
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			list.snapshot(`"sync1"`)
+		}
+	})
+}
@@ -0,0 +1,290 @@
+// Code generated by gosolo-gen. DO NOT EDIT.
+
+package gosolo
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/gopatchy/metadata"
+)
+
+type ShardServerConfig struct {
+	metadata.Metadata
+
+	ListETag   string `json:"-"`
+	InstanceID string `json:"instanceID,omitempty"`
+	ShardID    string `json:"shardID,omitempty"`
+
+	Unknown Unknown `json:"-"`
+}
+
+func (obj *ShardServerConfig) UnmarshalJSON(data []byte) error {
+	type plain ShardServerConfig
+	return UnmarshalWithUnknown(data, (*plain)(obj), &obj.Unknown)
+}
+
+func (obj ShardServerConfig) MarshalJSON() ([]byte, error) {
+	type plain ShardServerConfig
+	return MarshalWithUnknown(plain(obj), obj.Unknown)
+}
+
+type Task struct {
+	metadata.Metadata
+
+	ListETag string    `json:"-"`
+	After    time.Time `json:"after,omitempty"`
+	Complete bool      `json:"complete,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	UserID   string    `json:"userID,omitempty"`
+
+	Unknown Unknown `json:"-"`
+}
+
+func (obj *Task) UnmarshalJSON(data []byte) error {
+	type plain Task
+	return UnmarshalWithUnknown(data, (*plain)(obj), &obj.Unknown)
+}
+
+func (obj Task) MarshalJSON() ([]byte, error) {
+	type plain Task
+	return MarshalWithUnknown(plain(obj), obj.Unknown)
+}
+
+type Token struct {
+	metadata.Metadata
+
+	ListETag string `json:"-"`
+	Shard    string `json:"shard,omitempty"`
+	Token    string `json:"token,omitempty"`
+	UserID   string `json:"userID,omitempty"`
+
+	Unknown Unknown `json:"-"`
+}
+
+func (obj *Token) UnmarshalJSON(data []byte) error {
+	type plain Token
+	return UnmarshalWithUnknown(data, (*plain)(obj), &obj.Unknown)
+}
+
+func (obj Token) MarshalJSON() ([]byte, error) {
+	type plain Token
+	return MarshalWithUnknown(plain(obj), obj.Unknown)
+}
+
+type User struct {
+	metadata.Metadata
+
+	ListETag          string `json:"-"`
+	Email             string `json:"email,omitempty"`
+	Name              string `json:"name,omitempty"`
+	Password          string `json:"password,omitempty"`
+	ReplicationClient bool   `json:"replicationClient,omitempty"`
+	ServiceAdmin      bool   `json:"serviceAdmin,omitempty"`
+	Shard             string `json:"shard,omitempty"`
+
+	Unknown Unknown `json:"-"`
+}
+
+func (obj *User) UnmarshalJSON(data []byte) error {
+	type plain User
+	return UnmarshalWithUnknown(data, (*plain)(obj), &obj.Unknown)
+}
+
+func (obj User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return MarshalWithUnknown(plain(obj), obj.Unknown)
+}
+
+// resourceTypes maps API names to their types, for CheckSchema
+var resourceTypes = map[string]reflect.Type{
+	"shardserverconfig": reflect.TypeOf(ShardServerConfig{}),
+	"task":              reflect.TypeOf(Task{}),
+	"token":             reflect.TypeOf(Token{}),
+	"user":              reflect.TypeOf(User{}),
+}
+
+//// ShardServerConfig
+
+func (c *Client) CreateShardServerConfig(ctx context.Context, obj *ShardServerConfig) (*ShardServerConfig, error) {
+	return CreateName[ShardServerConfig](ctx, c, "shardserverconfig", obj)
+}
+
+func (c *Client) DeleteShardServerConfig(ctx context.Context, id string, opts *UpdateOpts[ShardServerConfig]) error {
+	return DeleteName[ShardServerConfig](ctx, c, "shardserverconfig", id, opts)
+}
+
+func (c *Client) DiffUpdateShardServerConfig(ctx context.Context, prev, next *ShardServerConfig) (*ShardServerConfig, error) {
+	return DiffUpdateName[ShardServerConfig](ctx, c, "shardserverconfig", prev, next)
+}
+
+func (c *Client) FindShardServerConfig(ctx context.Context, shortID string) (*ShardServerConfig, error) {
+	return FindName[ShardServerConfig](ctx, c, "shardserverconfig", shortID)
+}
+
+func (c *Client) GetShardServerConfig(ctx context.Context, id string, opts *GetOpts[ShardServerConfig]) (*ShardServerConfig, error) {
+	return GetName[ShardServerConfig](ctx, c, "shardserverconfig", id, opts)
+}
+
+func (c *Client) ListShardServerConfig(ctx context.Context, opts *ListOpts[ShardServerConfig]) ([]*ShardServerConfig, error) {
+	return ListName[ShardServerConfig](ctx, c, "shardserverconfig", opts)
+}
+
+func (c *Client) ReplaceShardServerConfig(ctx context.Context, id string, obj *ShardServerConfig, opts *UpdateOpts[ShardServerConfig]) (*ShardServerConfig, error) {
+	return ReplaceName[ShardServerConfig](ctx, c, "shardserverconfig", id, obj, opts)
+}
+
+func (c *Client) UpdateShardServerConfig(ctx context.Context, id string, obj *ShardServerConfig, opts *UpdateOpts[ShardServerConfig]) (*ShardServerConfig, error) {
+	return UpdateName[ShardServerConfig](ctx, c, "shardserverconfig", id, obj, opts)
+}
+
+func (c *Client) PatchShardServerConfig(ctx context.Context, id string, patch Patch, opts *UpdateOpts[ShardServerConfig]) (*ShardServerConfig, error) {
+	return PatchName[ShardServerConfig](ctx, c, "shardserverconfig", id, patch, opts)
+}
+
+func (c *Client) StreamGetShardServerConfig(ctx context.Context, id string, opts *GetOpts[ShardServerConfig]) (*GetStream[ShardServerConfig], error) {
+	return StreamGetName[ShardServerConfig](ctx, c, "shardserverconfig", id, opts)
+}
+
+func (c *Client) StreamListShardServerConfig(ctx context.Context, opts *ListOpts[ShardServerConfig]) (*ListStream[ShardServerConfig], error) {
+	return StreamListName[ShardServerConfig](ctx, c, "shardserverconfig", opts)
+}
+
+//// Task
+
+func (c *Client) CreateTask(ctx context.Context, obj *Task) (*Task, error) {
+	return CreateName[Task](ctx, c, "task", obj)
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id string, opts *UpdateOpts[Task]) error {
+	return DeleteName[Task](ctx, c, "task", id, opts)
+}
+
+func (c *Client) DiffUpdateTask(ctx context.Context, prev, next *Task) (*Task, error) {
+	return DiffUpdateName[Task](ctx, c, "task", prev, next)
+}
+
+func (c *Client) FindTask(ctx context.Context, shortID string) (*Task, error) {
+	return FindName[Task](ctx, c, "task", shortID)
+}
+
+func (c *Client) GetTask(ctx context.Context, id string, opts *GetOpts[Task]) (*Task, error) {
+	return GetName[Task](ctx, c, "task", id, opts)
+}
+
+func (c *Client) ListTask(ctx context.Context, opts *ListOpts[Task]) ([]*Task, error) {
+	return ListName[Task](ctx, c, "task", opts)
+}
+
+func (c *Client) ReplaceTask(ctx context.Context, id string, obj *Task, opts *UpdateOpts[Task]) (*Task, error) {
+	return ReplaceName[Task](ctx, c, "task", id, obj, opts)
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id string, obj *Task, opts *UpdateOpts[Task]) (*Task, error) {
+	return UpdateName[Task](ctx, c, "task", id, obj, opts)
+}
+
+func (c *Client) PatchTask(ctx context.Context, id string, patch Patch, opts *UpdateOpts[Task]) (*Task, error) {
+	return PatchName[Task](ctx, c, "task", id, patch, opts)
+}
+
+func (c *Client) StreamGetTask(ctx context.Context, id string, opts *GetOpts[Task]) (*GetStream[Task], error) {
+	return StreamGetName[Task](ctx, c, "task", id, opts)
+}
+
+func (c *Client) StreamListTask(ctx context.Context, opts *ListOpts[Task]) (*ListStream[Task], error) {
+	return StreamListName[Task](ctx, c, "task", opts)
+}
+
+//// Token
+
+func (c *Client) CreateToken(ctx context.Context, obj *Token) (*Token, error) {
+	return CreateName[Token](ctx, c, "token", obj)
+}
+
+func (c *Client) DeleteToken(ctx context.Context, id string, opts *UpdateOpts[Token]) error {
+	return DeleteName[Token](ctx, c, "token", id, opts)
+}
+
+func (c *Client) DiffUpdateToken(ctx context.Context, prev, next *Token) (*Token, error) {
+	return DiffUpdateName[Token](ctx, c, "token", prev, next)
+}
+
+func (c *Client) FindToken(ctx context.Context, shortID string) (*Token, error) {
+	return FindName[Token](ctx, c, "token", shortID)
+}
+
+func (c *Client) GetToken(ctx context.Context, id string, opts *GetOpts[Token]) (*Token, error) {
+	return GetName[Token](ctx, c, "token", id, opts)
+}
+
+func (c *Client) ListToken(ctx context.Context, opts *ListOpts[Token]) ([]*Token, error) {
+	return ListName[Token](ctx, c, "token", opts)
+}
+
+func (c *Client) ReplaceToken(ctx context.Context, id string, obj *Token, opts *UpdateOpts[Token]) (*Token, error) {
+	return ReplaceName[Token](ctx, c, "token", id, obj, opts)
+}
+
+func (c *Client) UpdateToken(ctx context.Context, id string, obj *Token, opts *UpdateOpts[Token]) (*Token, error) {
+	return UpdateName[Token](ctx, c, "token", id, obj, opts)
+}
+
+func (c *Client) PatchToken(ctx context.Context, id string, patch Patch, opts *UpdateOpts[Token]) (*Token, error) {
+	return PatchName[Token](ctx, c, "token", id, patch, opts)
+}
+
+func (c *Client) StreamGetToken(ctx context.Context, id string, opts *GetOpts[Token]) (*GetStream[Token], error) {
+	return StreamGetName[Token](ctx, c, "token", id, opts)
+}
+
+func (c *Client) StreamListToken(ctx context.Context, opts *ListOpts[Token]) (*ListStream[Token], error) {
+	return StreamListName[Token](ctx, c, "token", opts)
+}
+
+//// User
+
+func (c *Client) CreateUser(ctx context.Context, obj *User) (*User, error) {
+	return CreateName[User](ctx, c, "user", obj)
+}
+
+func (c *Client) DeleteUser(ctx context.Context, id string, opts *UpdateOpts[User]) error {
+	return DeleteName[User](ctx, c, "user", id, opts)
+}
+
+func (c *Client) DiffUpdateUser(ctx context.Context, prev, next *User) (*User, error) {
+	return DiffUpdateName[User](ctx, c, "user", prev, next)
+}
+
+func (c *Client) FindUser(ctx context.Context, shortID string) (*User, error) {
+	return FindName[User](ctx, c, "user", shortID)
+}
+
+func (c *Client) GetUser(ctx context.Context, id string, opts *GetOpts[User]) (*User, error) {
+	return GetName[User](ctx, c, "user", id, opts)
+}
+
+func (c *Client) ListUser(ctx context.Context, opts *ListOpts[User]) ([]*User, error) {
+	return ListName[User](ctx, c, "user", opts)
+}
+
+func (c *Client) ReplaceUser(ctx context.Context, id string, obj *User, opts *UpdateOpts[User]) (*User, error) {
+	return ReplaceName[User](ctx, c, "user", id, obj, opts)
+}
+
+func (c *Client) UpdateUser(ctx context.Context, id string, obj *User, opts *UpdateOpts[User]) (*User, error) {
+	return UpdateName[User](ctx, c, "user", id, obj, opts)
+}
+
+func (c *Client) PatchUser(ctx context.Context, id string, patch Patch, opts *UpdateOpts[User]) (*User, error) {
+	return PatchName[User](ctx, c, "user", id, patch, opts)
+}
+
+func (c *Client) StreamGetUser(ctx context.Context, id string, opts *GetOpts[User]) (*GetStream[User], error) {
+	return StreamGetName[User](ctx, c, "user", id, opts)
+}
+
+func (c *Client) StreamListUser(ctx context.Context, opts *ListOpts[User]) (*ListStream[User], error) {
+	return StreamListName[User](ctx, c, "user", opts)
+}
@@ -0,0 +1,173 @@
+package gosolo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tasksolo/gosolo/internal/openapi"
+)
+
+type SchemaDiffKind int
+
+const (
+	// The server has a field (or resource) the client doesn't
+	SchemaMissing SchemaDiffKind = iota
+
+	// The client has a field (or resource) the server doesn't
+	SchemaExtra
+
+	// Both have the field, with different types
+	SchemaTypeChanged
+)
+
+type SchemaDiff struct {
+	Kind     SchemaDiffKind
+	Resource string
+
+	// Empty for a whole resource
+	Field string
+
+	// Go types; empty on the side that lacks the field
+	ClientType string
+	ServerType string
+}
+
+// CheckSchema compares the server's _openapi document with the compiled
+// resource structs and returns every difference, sorted by resource and
+// field. No differences means resources.go is current.
+func (c *Client) CheckSchema(ctx context.Context) ([]*SchemaDiff, error) {
+	doc, err := c.OpenAPI(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	typeNames := map[string]string{}
+
+	for name, typ := range resourceTypes {
+		typeNames[name] = typ.Name()
+	}
+
+	resources, err := openapi.Resources(doc, typeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []*SchemaDiff{}
+	served := map[string]bool{}
+
+	for _, res := range resources {
+		served[res.Name] = true
+
+		typ := resourceTypes[res.Name]
+		if typ == nil {
+			ret = append(ret, &SchemaDiff{Kind: SchemaMissing, Resource: res.Name})
+			continue
+		}
+
+		ret = append(ret, diffFields(res, typ)...)
+	}
+
+	for name := range resourceTypes {
+		if !served[name] {
+			ret = append(ret, &SchemaDiff{Kind: SchemaExtra, Resource: name})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Resource != ret[j].Resource {
+			return ret[i].Resource < ret[j].Resource
+		}
+
+		return ret[i].Field < ret[j].Field
+	})
+
+	return ret, nil
+}
+
+func (diff *SchemaDiff) String() string {
+	what := diff.Resource
+	if diff.Field != "" {
+		what += "." + diff.Field
+	}
+
+	switch diff.Kind {
+	case SchemaMissing:
+		return fmt.Sprintf("%s: on server, missing from client", what)
+
+	case SchemaExtra:
+		return fmt.Sprintf("%s: in client, not on server", what)
+
+	default:
+		return fmt.Sprintf("%s: client has %s, server has %s", what, diff.ClientType, diff.ServerType)
+	}
+}
+
+func diffFields(res *openapi.Resource, typ reflect.Type) []*SchemaDiff {
+	ret := []*SchemaDiff{}
+	clientFields := jsonFields(typ)
+
+	for _, field := range res.Fields {
+		clientType, found := clientFields[field.JSONName]
+		delete(clientFields, field.JSONName)
+
+		switch {
+		case !found:
+			ret = append(ret, &SchemaDiff{
+				Kind:       SchemaMissing,
+				Resource:   res.Name,
+				Field:      field.JSONName,
+				ServerType: field.GoType,
+			})
+
+		case clientType != field.GoType:
+			ret = append(ret, &SchemaDiff{
+				Kind:       SchemaTypeChanged,
+				Resource:   res.Name,
+				Field:      field.JSONName,
+				ClientType: clientType,
+				ServerType: field.GoType,
+			})
+		}
+	}
+
+	for name, clientType := range clientFields {
+		ret = append(ret, &SchemaDiff{
+			Kind:       SchemaExtra,
+			Resource:   res.Name,
+			Field:      name,
+			ClientType: clientType,
+		})
+	}
+
+	return ret
+}
+
+// jsonFields maps JSON names to Go types, skipping metadata and json:"-"
+func jsonFields(typ reflect.Type) map[string]string {
+	ret := map[string]string{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		ret[name] = strings.ReplaceAll(field.Type.String(), "interface {}", "any")
+	}
+
+	return ret
+}
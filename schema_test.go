@@ -0,0 +1,106 @@
+package gosolo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+)
+
+func TestCheckSchema(t *testing.T) {
+	t.Parallel()
+
+	// The document resources.go is generated from
+	js, err := os.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := map[string]any{}
+
+	err = json.Unmarshal(js, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/_openapi" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := gosolo.NewClientDirect(srv.URL)
+
+	diffs, err := c.CheckSchema(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, diff := range diffs {
+		t.Errorf("unexpected diff: %s", diff)
+	}
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	paths := doc["paths"].(map[string]any)
+
+	taskProps := props(schemas["task"])
+	taskProps["tags"] = map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	taskProps["complete"] = map[string]any{"type": "string"}
+
+	delete(props(schemas["token"]), "shard")
+
+	delete(paths, "/shardserverconfig")
+	delete(paths, "/shardserverconfig/{id}")
+
+	paths["/widget"] = map[string]any{}
+	paths["/widget/{id}"] = map[string]any{}
+	schemas["widget"] = map[string]any{"type": "object", "properties": map[string]any{"size": map[string]any{"type": "integer"}}}
+
+	diffs, err = c.CheckSchema(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"shardserverconfig: in client, not on server",
+		"task.complete: client has bool, server has string",
+		"task.tags: on server, missing from client",
+		"token.shard: in client, not on server",
+		"widget: on server, missing from client",
+	}
+
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs, want %d: %v", len(diffs), len(want), diffs)
+	}
+
+	for i, diff := range diffs {
+		if diff.String() != want[i] {
+			t.Errorf("diff %d: got %q, want %q", i, diff, want[i])
+		}
+	}
+
+	if diffs[2].Kind != gosolo.SchemaMissing || diffs[2].ServerType != "[]string" {
+		t.Errorf("tags diff: %+v", diffs[2])
+	}
+}
+
+// props returns the properties of an allOf schema's inline member
+func props(schema any) map[string]any {
+	for _, sub := range schema.(map[string]any)["allOf"].([]any) {
+		if p, ok := sub.(map[string]any)["properties"].(map[string]any); ok {
+			return p
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package gosolo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gopatchy/metadata"
+)
+
+// MultipleFoundError is returned by Find* when shortID prefixes more than
+// one ID.
+type MultipleFoundError[T any] struct {
+	ShortID    string
+	Candidates []*T
+}
+
+func (err *MultipleFoundError[T]) Error() string {
+	return fmt.Sprintf("%s matches %s (%s)", err.ShortID, strings.Join(err.CandidateIDs(), ", "), ErrMultipleFound)
+}
+
+func (err *MultipleFoundError[T]) Unwrap() error {
+	return ErrMultipleFound
+}
+
+func (err *MultipleFoundError[T]) CandidateIDs() []string {
+	ret := []string{}
+
+	for _, obj := range err.Candidates {
+		ret = append(ret, metadata.GetMetadata(obj).ID)
+	}
+
+	return ret
+}
+
+// ShortIDs returns, in list order, the shortest prefix of each object's ID
+// (at least minLen long) that no other ID in list shares. They resolve via
+// Find* as long as list is the whole collection and no new ID shares the
+// prefix.
+func ShortIDs[T any](list []*T, minLen int) []string {
+	ids := []string{}
+
+	for _, obj := range list {
+		ids = append(ids, metadata.GetMetadata(obj).ID)
+	}
+
+	return UniquePrefixes(ids, minLen)
+}
+
+// UniquePrefixes is ShortIDs for plain IDs.
+func UniquePrefixes(ids []string, minLen int) []string {
+	sorted := append([]string{}, ids...)
+	sort.Strings(sorted)
+
+	lengths := map[string]int{}
+
+	for i, id := range sorted {
+		n := minLen
+
+		// Only sorted neighbors can share a longer prefix
+		if i > 0 {
+			n = maxInt(n, commonPrefixLen(id, sorted[i-1])+1)
+		}
+
+		if i < len(sorted)-1 {
+			n = maxInt(n, commonPrefixLen(id, sorted[i+1])+1)
+		}
+
+		lengths[id] = n
+	}
+
+	ret := []string{}
+
+	for _, id := range ids {
+		n := lengths[id]
+		if n > len(id) {
+			n = len(id)
+		}
+
+		ret = append(ret, id[:n])
+	}
+
+	return ret
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return n
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
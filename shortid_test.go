@@ -0,0 +1,140 @@
+package gosolo_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gopatchy/metadata"
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+)
+
+func TestUniquePrefixes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		ids    []string
+		minLen int
+		want   []string
+	}{
+		{
+			name:   "minLen suffices",
+			ids:    []string{"abc123", "def456"},
+			minLen: 2,
+			want:   []string{"ab", "de"},
+		},
+		{
+			name:   "neighbors extend",
+			ids:    []string{"abd000", "abc999", "xyz000"},
+			minLen: 1,
+			want:   []string{"abd", "abc", "x"},
+		},
+		{
+			name:   "non-neighbor doesn't extend",
+			ids:    []string{"aaaa", "aabb", "abbb"},
+			minLen: 1,
+			want:   []string{"aaa", "aab", "ab"},
+		},
+		{
+			name:   "minLen past the end",
+			ids:    []string{"ab", "cdef"},
+			minLen: 3,
+			want:   []string{"ab", "cde"},
+		},
+		{
+			name:   "duplicates stay whole",
+			ids:    []string{"abcd", "abcd", "abef"},
+			minLen: 1,
+			want:   []string{"abcd", "abcd", "abe"},
+		},
+		{
+			name:   "full ID prefixes another",
+			ids:    []string{"abcd", "abc"},
+			minLen: 1,
+			want:   []string{"abcd", "abc"},
+		},
+		{
+			name:   "empty",
+			ids:    []string{},
+			minLen: 4,
+			want:   []string{},
+		},
+	}
+
+	for _, test := range tests {
+		got := gosolo.UniquePrefixes(test.ids, test.minLen)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: UniquePrefixes(%v, %d) = %v, want %v", test.name, test.ids, test.minLen, got, test.want)
+		}
+	}
+}
+
+func TestShortIDs(t *testing.T) {
+	t.Parallel()
+
+	list := []*gosolo.Task{
+		{Metadata: metadata.Metadata{ID: "task0002"}},
+		{Metadata: metadata.Metadata{ID: "task0001"}},
+		{Metadata: metadata.Metadata{ID: "other"}},
+	}
+
+	got := gosolo.ShortIDs(list, 2)
+	want := []string{"task0002", "task0001", "ot"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ShortIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestFindTask(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	for _, id := range []string{"abc", "abcd", "abce", "xyz"} {
+		srv.Put("task", map[string]any{"id": id, "name": id})
+	}
+
+	c := gosolo.NewClientDirect(srv.URL)
+
+	task, err := c.FindTask(ctx, "x")
+	if err != nil || task.ID != "xyz" {
+		t.Errorf("FindTask(x) = %v, %v", task, err)
+	}
+
+	// Prefixes abcd and abce too, but matches abc exactly
+	task, err = c.FindTask(ctx, "abc")
+	if err != nil || task.ID != "abc" {
+		t.Errorf("FindTask(abc) = %v, %v", task, err)
+	}
+
+	_, err = c.FindTask(ctx, "ab")
+
+	multi := &gosolo.MultipleFoundError[gosolo.Task]{}
+	if !errors.As(err, &multi) {
+		t.Fatalf("FindTask(ab) = %v", err)
+	}
+
+	if !errors.Is(err, gosolo.ErrMultipleFound) || multi.ShortID != "ab" {
+		t.Errorf("FindTask(ab) = %v", err)
+	}
+
+	ids := multi.CandidateIDs()
+	sort.Strings(ids)
+
+	if !reflect.DeepEqual(ids, []string{"abc", "abcd", "abce"}) {
+		t.Errorf("candidates = %v", ids)
+	}
+
+	_, err = c.FindTask(ctx, "q")
+	if !errors.Is(err, gosolo.ErrNotFound) {
+		t.Errorf("FindTask(q) = %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package gosolo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/gopatchy/metadata"
+)
+
+// SnapshotDiff is what changed between two lists of the same resource.
+type SnapshotDiff[T any] struct {
+	// In next order
+	Added []*T
+
+	// In prev order
+	Removed []*T
+
+	// In next order
+	Changed []*ObjectChange[T]
+}
+
+type ObjectChange[T any] struct {
+	Prev *T
+	Next *T
+
+	// Sorted by Field
+	Fields []*FieldChange
+}
+
+// FieldChange is a top-level JSON field that differs. Prev or Next is nil
+// when the field is absent (usually a zero value) on that side.
+type FieldChange struct {
+	Field string
+	Prev  any
+	Next  any
+}
+
+// DiffSnapshots compares two lists by metadata ID. Objects whose ETags
+// match are assumed unchanged; others are compared field by field. Works
+// on successive ListStream events or ListName polls alike.
+func DiffSnapshots[T any](prev, next []*T) (*SnapshotDiff[T], error) {
+	ret := &SnapshotDiff[T]{
+		Added:   []*T{},
+		Removed: []*T{},
+		Changed: []*ObjectChange[T]{},
+	}
+
+	prevByID := map[string]*T{}
+
+	for _, obj := range prev {
+		prevByID[metadata.GetMetadata(obj).ID] = obj
+	}
+
+	nextIDs := map[string]bool{}
+
+	for _, obj := range next {
+		md := metadata.GetMetadata(obj)
+		nextIDs[md.ID] = true
+
+		old := prevByID[md.ID]
+		if old == nil {
+			ret.Added = append(ret.Added, obj)
+			continue
+		}
+
+		oldMD := metadata.GetMetadata(old)
+		if old == obj || (md.ETag != "" && md.ETag == oldMD.ETag) {
+			continue
+		}
+
+		fields, err := fieldChanges(old, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		ret.Changed = append(ret.Changed, &ObjectChange[T]{
+			Prev:   old,
+			Next:   obj,
+			Fields: fields,
+		})
+	}
+
+	for _, obj := range prev {
+		if !nextIDs[metadata.GetMetadata(obj).ID] {
+			ret.Removed = append(ret.Removed, obj)
+		}
+	}
+
+	return ret, nil
+}
+
+// Empty reports whether nothing changed.
+func (diff *SnapshotDiff[T]) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// DiffChan diffs each list from in (e.g. ListStream.Chan()) against the one
+// before it, skipping lists with no changes. The first list is all Added.
+// The diff channel closes when in closes, ctx is done or a diff fails; the
+// error channel then yields the reason (nil when in closed) and closes.
+func DiffChan[T any](ctx context.Context, in <-chan []*T) (<-chan *SnapshotDiff[T], <-chan error) {
+	out := make(chan *SnapshotDiff[T], 1)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+
+		errc <- diffChan(ctx, in, out)
+	}()
+
+	return out, errc
+}
+
+func diffChan[T any](ctx context.Context, in <-chan []*T, out chan<- *SnapshotDiff[T]) error {
+	var prev []*T
+
+	for {
+		var (
+			list []*T
+			ok   bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case list, ok = <-in:
+			if !ok {
+				return nil
+			}
+		}
+
+		diff, err := DiffSnapshots(prev, list)
+		if err != nil {
+			return err
+		}
+
+		prev = list
+
+		if diff.Empty() {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case out <- diff:
+		}
+	}
+}
+
+func fieldChanges(prev, next any) ([]*FieldChange, error) {
+	from, to, err := diffObjects(prev, next)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := sortedKeys(from)
+
+	for key := range to {
+		if _, found := from[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	ret := []*FieldChange{}
+
+	for _, key := range keys {
+		if reflect.DeepEqual(from[key], to[key]) {
+			continue
+		}
+
+		ret = append(ret, &FieldChange{
+			Field: key,
+			Prev:  from[key],
+			Next:  to[key],
+		})
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,120 @@
+package gosolo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gopatchy/metadata"
+	"github.com/tasksolo/gosolo"
+)
+
+func task(id, etag, name string) *gosolo.Task {
+	return &gosolo.Task{
+		Metadata: metadata.Metadata{ID: id, ETag: etag},
+		Name:     name,
+	}
+}
+
+func TestDiffChan(t *testing.T) {
+	t.Parallel()
+
+	a1 := task("a", "a1", "A")
+	b1 := task("b", "b1", "B")
+	b2 := task("b", "b2", "B2")
+
+	in := make(chan []*gosolo.Task, 4)
+	in <- []*gosolo.Task{a1}
+	in <- []*gosolo.Task{a1, b1}
+	in <- []*gosolo.Task{a1, b1}
+	in <- []*gosolo.Task{b2}
+	close(in)
+
+	diffs, errc := gosolo.DiffChan(context.Background(), in)
+
+	got := []*gosolo.SnapshotDiff[gosolo.Task]{}
+
+	for diff := range diffs {
+		got = append(got, diff)
+	}
+
+	err := <-errc
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("got %d diffs, want 3", len(got))
+	}
+
+	if len(got[0].Added) != 1 || got[0].Added[0] != a1 {
+		t.Errorf("diff 0: %+v", got[0])
+	}
+
+	if len(got[1].Added) != 1 || got[1].Added[0] != b1 {
+		t.Errorf("diff 1: %+v", got[1])
+	}
+
+	if len(got[2].Removed) != 1 || got[2].Removed[0] != a1 ||
+		len(got[2].Changed) != 1 || got[2].Changed[0].Fields[0].Field != "name" {
+		t.Errorf("diff 2: %+v", got[2])
+	}
+}
+
+type unmarshalable struct {
+	metadata.Metadata
+
+	Fn func() `json:"fn"`
+}
+
+func TestDiffChanError(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan []*unmarshalable, 2)
+	in <- []*unmarshalable{{Metadata: metadata.Metadata{ID: "a"}}}
+	in <- []*unmarshalable{{Metadata: metadata.Metadata{ID: "a"}}}
+
+	diffs, errc := gosolo.DiffChan(context.Background(), in)
+
+	count := 0
+
+	for range diffs {
+		count++
+	}
+
+	// The first list is all Added, without marshaling
+	if count != 1 {
+		t.Errorf("got %d diffs, want 1", count)
+	}
+
+	err := <-errc
+	if err == nil {
+		t.Fatal("expected marshal error")
+	}
+}
+
+func TestDiffChanCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Never closed, and the diffs are never read
+	in := make(chan []*gosolo.Task)
+	_, errc := gosolo.DiffChan(ctx, in)
+
+	in <- []*gosolo.Task{task("a", "a1", "A")}
+	in <- []*gosolo.Task{task("b", "b1", "B")}
+
+	cancel()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("DiffChan goroutine still running after cancel")
+	}
+}
@@ -0,0 +1,225 @@
+package gosolo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// DefaultStreamMaxEventSize bounds each stream event unless
+// SetStreamMaxEventSize says otherwise.
+const DefaultStreamMaxEventSize = 64 << 20
+
+var ErrStreamEventTooLarge = fmt.Errorf("stream event too large")
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+type streamEvent[T any] struct {
+	eventType string
+	params    map[string]string
+	data      []byte
+}
+
+// eventStream parses text/event-stream as specified by WHATWG HTML (9.2),
+// except that events are dispatched with no data (e.g. heartbeat), and
+// fields the spec ignores are kept in params (e.g. new-position). Each
+// event's id is in params rather than carried over, since resuming is up
+// to the caller. The retry hint survives reset(), like EventSource's.
+type eventStream[T any] struct {
+	scan    *bufio.Scanner
+	maxSize int
+	first   bool
+	retry   time.Duration
+}
+
+// SetStreamMaxEventSize limits the size of one event (and so of one line)
+// read from a stream. Larger events fail the stream with
+// ErrStreamEventTooLarge.
+func (c *Client) SetStreamMaxEventSize(size int) *Client {
+	c.streamMaxEventSize = size
+	return c
+}
+
+// SetStreamResume lets diff list streams pick up where they left off after
+// a reconnect, rather than start over from an empty list. Only enable it
+// for servers that implement this contract, which stock patchy servers
+// don't: the client sends the id of the last sync event as Last-Event-ID;
+// a server that can continue from there replies with a Stream-Resume
+// header equal to that id and sends only the changes since. Any other
+// reply starts over, and a 4xx reply is retried once without the id.
+func (c *Client) SetStreamResume(resume bool) *Client {
+	c.streamResume = resume
+	return c
+}
+
+func newStreamEvent[T any]() *streamEvent[T] {
+	return &streamEvent[T]{
+		params: map[string]string{},
+	}
+}
+
+func (ev *streamEvent[T]) decodeObj() (*T, error) {
+	obj := new(T)
+
+	err := json.Unmarshal(ev.data, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (ev *streamEvent[T]) decodeList() ([]*T, error) {
+	list := []*T{}
+
+	err := json.Unmarshal(ev.data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func newEventStream[T any](maxSize int) *eventStream[T] {
+	if maxSize <= 0 {
+		maxSize = DefaultStreamMaxEventSize
+	}
+
+	return &eventStream[T]{
+		maxSize: maxSize,
+	}
+}
+
+func (es *eventStream[T]) reset(body io.Reader) {
+	es.scan = bufio.NewScanner(body)
+	es.scan.Buffer(make([]byte, 0, minInt(4096, es.maxSize)), es.maxSize)
+	es.scan.Split(scanEventLines)
+	es.first = true
+}
+
+func (es *eventStream[T]) readEvent() (*streamEvent[T], error) {
+	event := newStreamEvent[T]()
+	data := [][]byte{}
+	size := 0
+	pending := false
+
+	// TODO: Add a timeout (15s?) here that causes us to return error, closing the stream
+
+	for es.scan.Scan() {
+		line := es.scan.Bytes()
+
+		if es.first {
+			line = bytes.TrimPrefix(line, utf8BOM)
+			es.first = false
+		}
+
+		if len(line) == 0 {
+			if !pending {
+				continue
+			}
+
+			if event.eventType == "" {
+				event.eventType = "message"
+			}
+
+			event.data = bytes.Join(data, []byte("\n"))
+
+			return event, nil
+		}
+
+		if line[0] == ':' {
+			continue
+		}
+
+		pending = true
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+
+		switch string(field) {
+		case "event":
+			event.eventType = string(value)
+
+		case "data":
+			size += len(value) + 1
+			if size > es.maxSize {
+				return nil, fmt.Errorf("over %d bytes (%w)", es.maxSize, ErrStreamEventTooLarge)
+			}
+
+			// value is only valid until the next Scan()
+			data = append(data, append([]byte{}, value...))
+
+		case "id":
+			if bytes.IndexByte(value, 0) == -1 {
+				event.params["id"] = string(value)
+			}
+
+		case "retry":
+			ms, err := strconv.ParseUint(string(value), 10, 32)
+			if err == nil {
+				es.retry = time.Duration(ms) * time.Millisecond
+			}
+
+		default:
+			event.params[string(field)] = string(value)
+		}
+	}
+
+	err := es.scan.Err()
+
+	switch {
+	case errors.Is(err, bufio.ErrTooLong):
+		return nil, fmt.Errorf("line over %d bytes (%w)", es.maxSize, ErrStreamEventTooLarge)
+
+	case err != nil:
+		return nil, err
+
+	default:
+		return nil, io.EOF
+	}
+}
+
+// scanEventLines is a bufio.SplitFunc for lines ending in CRLF, LF or CR
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexAny(data, "\r\n")
+
+	switch {
+	case i == -1:
+		if atEOF && len(data) > 0 {
+			// Unterminated, so never completes an event
+			return len(data), data, nil
+		}
+
+		return 0, nil, nil
+
+	case data[i] == '\n':
+		return i + 1, data[:i], nil
+
+	case i+1 < len(data):
+		if data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+
+		return i + 1, data[:i], nil
+
+	case atEOF:
+		return i + 1, data[:i], nil
+
+	default:
+		// Need the next byte to tell CR from CRLF
+		return 0, nil, nil
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,191 @@
+package gosolo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAllEvents(body string, maxSize int) ([]*streamEvent[Task], *eventStream[Task], error) {
+	es := newEventStream[Task](maxSize)
+	es.reset(strings.NewReader(body))
+
+	ret := []*streamEvent[Task]{}
+
+	for {
+		event, err := es.readEvent()
+		if err != nil {
+			return ret, es, err
+		}
+
+		ret = append(ret, event)
+	}
+}
+
+func TestReadEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+
+		// eventType|id|data per event
+		want []string
+	}{
+		{
+			name: "lf",
+			body: "event: list\nid: 1\ndata: [1,\ndata: 2]\n\n",
+			want: []string{"list|1|[1,\n2]"},
+		},
+		{
+			name: "crlf and cr",
+			body: "event: a\r\ndata: x\r\n\r\nevent: b\rdata:y\r\r",
+			want: []string{"a||x", "b||y"},
+		},
+		{
+			name: "bom, comments and blank lines",
+			body: "\xef\xbb\xbf: hello\n\n\nevent: heartbeat\n\n",
+			want: []string{"heartbeat||"},
+		},
+		{
+			name: "default type",
+			body: "data: {}\n\n",
+			want: []string{"message||{}"},
+		},
+		{
+			name: "nul in id is ignored",
+			body: "id: a\x00b\ndata: 1\n\n",
+			want: []string{"message||1"},
+		},
+		{
+			name: "field without colon",
+			body: "event: add\nnew-position: 3\ndata\n\n",
+			want: []string{"add||"},
+		},
+		{
+			name: "unterminated event is dropped",
+			body: "event: a\n\nevent: b\ndata: 1",
+			want: []string{"a||"},
+		},
+	}
+
+	for _, test := range tests {
+		events, _, err := readAllEvents(test.body, 0)
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("%s: %v", test.name, err)
+		}
+
+		got := []string{}
+
+		for _, event := range events {
+			got = append(got, event.eventType+"|"+event.params["id"]+"|"+string(event.data))
+		}
+
+		if strings.Join(got, "\n---\n") != strings.Join(test.want, "\n---\n") {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadEventParams(t *testing.T) {
+	t.Parallel()
+
+	events, es, _ := readAllEvents("event: add\nnew-position: 3\nretry: 1500\ndata: {}\n\nretry: x\n\n", 0)
+
+	if len(events) != 2 || events[0].params["new-position"] != "3" {
+		t.Fatalf("events: %+v", events)
+	}
+
+	// Invalid retry values are ignored
+	if es.retry != 1500*time.Millisecond {
+		t.Errorf("retry %s", es.retry)
+	}
+}
+
+func TestReadEventTooLarge(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		"data: " + strings.Repeat("x", 100) + "\n\n",
+		"data: " + strings.Repeat("x", 40) + "\ndata: " + strings.Repeat("x", 40) + "\n\n",
+	} {
+		_, _, err := readAllEvents(body, 64)
+		if !errors.Is(err, ErrStreamEventTooLarge) {
+			t.Errorf("got %v, want ErrStreamEventTooLarge", err)
+		}
+	}
+}
+
+func FuzzReadEvent(f *testing.F) {
+	for _, seed := range []string{
+		"event: list\nid: 1\ndata: [1,\ndata: 2]\n\n",
+		"event: a\r\ndata: x\r\n\r\nevent: b\rdata:y\r\r",
+		"\xef\xbb\xbf: c\n\nretry: 10\nid\n\n",
+		"event: add\nnew-position: 0\ndata: {}\n\n",
+		"data: " + strings.Repeat("x", 100) + "\n\n",
+		"\r",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	const maxSize = 64
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		events, _, err := readAllEvents(string(body), maxSize)
+		if !errors.Is(err, io.EOF) && !errors.Is(err, ErrStreamEventTooLarge) {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		for _, event := range events {
+			if len(event.data) > maxSize {
+				t.Errorf("event data %d bytes, over %d", len(event.data), maxSize)
+			}
+
+			if event.eventType == "" {
+				t.Error("empty event type")
+			}
+
+			if strings.ContainsAny(event.eventType+event.params["id"], "\r\n") {
+				t.Errorf("line break in event %q id %q", event.eventType, event.params["id"])
+			}
+		}
+
+		// Splitting lines differently must not change the events
+		if !errors.Is(err, io.EOF) {
+			return
+		}
+
+		es := newEventStream[Task](maxSize)
+		es.reset(&oneByteReader{r: bytes.NewReader(body)})
+
+		for i := 0; ; i++ {
+			event, err := es.readEvent()
+			if err != nil {
+				if i != len(events) {
+					t.Errorf("byte at a time: %d events, want %d", i, len(events))
+				}
+
+				return
+			}
+
+			if i >= len(events) || event.eventType != events[i].eventType || !bytes.Equal(event.data, events[i].data) {
+				t.Fatalf("byte at a time: event %d differs", i)
+			}
+		}
+	})
+}
+
+type oneByteReader struct {
+	r io.Reader
+}
+
+func (obr *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	return obr.r.Read(p[:1])
+}
@@ -0,0 +1,174 @@
+package gosolo_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+)
+
+// diffServer serves one diff list stream per entry in conns, in order,
+// recording each request's Last-Event-ID. The last connection stays open.
+func diffServer(t *testing.T, conns ...func(w http.ResponseWriter)) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	mu := sync.Mutex{}
+	ids := []string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n := len(ids)
+		ids = append(ids, r.Header.Get("Last-Event-ID"))
+		mu.Unlock()
+
+		if n >= len(conns) {
+			<-r.Context().Done()
+			return
+		}
+
+		conns[n](w)
+
+		if n == len(conns)-1 {
+			<-r.Context().Done()
+		}
+	}))
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string{}, ids...)
+	}
+}
+
+func diffStart(w http.ResponseWriter, resume string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Stream-Format", "diff")
+
+	if resume != "" {
+		w.Header().Set("Stream-Resume", resume)
+	}
+}
+
+func diffAdd(w http.ResponseWriter, pos int, id, name string) {
+	fmt.Fprintf(w, "event: add\nnew-position: %d\ndata: {\"id\":%q,\"etag\":\"etag:%s\",\"name\":%q}\n\n", pos, id, id, name)
+}
+
+func diffSync(w http.ResponseWriter, id string) {
+	fmt.Fprintf(w, "event: sync\nid: %s\n\n", id)
+	w.(http.Flusher).Flush()
+}
+
+func listIDs(list []*gosolo.Token) string {
+	ret := ""
+
+	for _, obj := range list {
+		ret += obj.ID + ","
+	}
+
+	return ret
+}
+
+func firstConn(w http.ResponseWriter) {
+	diffStart(w, "")
+	diffAdd(w, 0, "t1", "A")
+	diffSync(w, "s1")
+}
+
+func freshConn(w http.ResponseWriter) {
+	diffStart(w, "")
+	diffAdd(w, 0, "t2", "B")
+	diffSync(w, "s2")
+}
+
+func TestStreamResume(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		resume bool
+
+		// Connections after the first
+		conns []func(w http.ResponseWriter)
+
+		wantIDs  []string
+		wantList string
+	}{
+		{
+			name:   "resumed",
+			resume: true,
+			conns: []func(w http.ResponseWriter){func(w http.ResponseWriter) {
+				diffStart(w, "s1")
+				diffAdd(w, 1, "t2", "B")
+				diffSync(w, "s2")
+			}},
+			wantIDs:  []string{"", "s1"},
+			wantList: "t1,t2,",
+		},
+		{
+			name:     "server starts over",
+			resume:   true,
+			conns:    []func(w http.ResponseWriter){freshConn},
+			wantIDs:  []string{"", "s1"},
+			wantList: "t2,",
+		},
+		{
+			name:   "server rejects id",
+			resume: true,
+			conns: []func(w http.ResponseWriter){func(w http.ResponseWriter) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"messages":["unknown event id"]}`)
+			}, freshConn},
+			wantIDs:  []string{"", "s1", ""},
+			wantList: "t2,",
+		},
+		{
+			name: "not enabled",
+			// Even a server claiming to resume isn't trusted
+			conns: []func(w http.ResponseWriter){func(w http.ResponseWriter) {
+				diffStart(w, "s1")
+				diffAdd(w, 0, "t2", "B")
+				diffSync(w, "s2")
+			}},
+			wantIDs:  []string{"", ""},
+			wantList: "t2,",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, ids := diffServer(t, append([]func(w http.ResponseWriter){firstConn}, test.conns...)...)
+			defer srv.Close()
+
+			c := gosolo.NewClientDirect(srv.URL).SetStreamResume(test.resume)
+
+			stream, err := c.StreamListToken(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer stream.Close()
+
+			if got := listIDs(stream.Read()); got != "t1," {
+				t.Fatalf("first sync: %s", got)
+			}
+
+			if got := listIDs(stream.Read()); got != test.wantList {
+				t.Errorf("after reconnect: %s, want %s", got, test.wantList)
+			}
+
+			if got := ids(); fmt.Sprint(got) != fmt.Sprint(test.wantIDs) {
+				t.Errorf("Last-Event-ID per request: %q, want %q", got, test.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+// Series is a recurring task: a rule, its start, and the task that is the
+// current occurrence.
+type Series struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	RRule  string    `json:"rrule"`
+	Start  time.Time `json:"start"`
+	TaskID string    `json:"taskID"`
+
+	// IANA name, so occurrences keep their wall clock time across DST
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Set by Reserve while a reconciler creates the next occurrence
+	ReservedBy    string    `json:"reservedBy,omitempty"`
+	ReservedUntil time.Time `json:"reservedUntil,omitempty"`
+}
+
+// SeriesStore persists Series. Reserve and Advance must each be atomic
+// across every Reconciler sharing the store; together they stop two of them
+// creating the same occurrence. Reconcilers on different hosts need a store
+// they all reach with those guarantees (FileSeriesStore only provides them
+// on one host).
+type SeriesStore interface {
+	List(ctx context.Context) ([]*Series, error)
+	Put(ctx context.Context, series *Series) error
+	Delete(ctx context.Context, id string) error
+
+	// Reserve lets holder create the occurrence that follows fromTaskID,
+	// until expires. It returns ErrSeriesConflict if the current task is no
+	// longer fromTaskID or another holder's reservation hasn't expired.
+	Reserve(ctx context.Context, id, fromTaskID, holder string, expires time.Time) error
+
+	// Advance moves the series' current task from fromTaskID to toTaskID and
+	// drops any reservation. It succeeds without change if the current task
+	// is already toTaskID, and otherwise returns ErrSeriesConflict if the
+	// current task is no longer fromTaskID.
+	Advance(ctx context.Context, id, fromTaskID, toTaskID string) error
+}
+
+type Reconciler struct {
+	client *gosolo.Client
+	store  SeriesStore
+
+	// Holder name for reservations
+	id string
+}
+
+var ErrSeriesConflict = fmt.Errorf("series advanced concurrently")
+
+// How long a reservation holds if its reconciler dies before advancing
+const reserveTTL = 5 * time.Minute
+
+func NewReconciler(c *gosolo.Client, store SeriesStore) *Reconciler {
+	return &Reconciler{
+		client: c,
+		store:  store,
+		id:     newSeriesID(),
+	}
+}
+
+// CreateSeries validates rrule, creates the first occurrence at or after
+// start, and stores the series.
+func (r *Reconciler) CreateSeries(ctx context.Context, name, rrule string, start time.Time) (*Series, *gosolo.Task, error) {
+	rule, err := ParseRRule(rrule)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	first, ok := rule.Next(start, start.Add(-time.Nanosecond))
+	if !ok {
+		return nil, nil, fmt.Errorf("%s has no occurrences (%w)", rrule, ErrInvalidRRule)
+	}
+
+	task, err := r.client.CreateTask(ctx, &gosolo.Task{
+		Name:  name,
+		After: first,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	series := &Series{
+		ID:       newSeriesID(),
+		Name:     name,
+		RRule:    rule.String(),
+		Start:    start,
+		TaskID:   task.ID,
+		TimeZone: start.Location().String(),
+	}
+
+	err = r.store.Put(ctx, series)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return series, task, nil
+}
+
+// ReconcileOnce advances every series whose current task is complete.
+func (r *Reconciler) ReconcileOnce(ctx context.Context) error {
+	list, err := r.client.ListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return r.reconcile(ctx, list)
+}
+
+// Run reconciles on every update from StreamListTask until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	stream, err := r.client.StreamListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer stream.Close()
+
+	for list := range stream.Chan() {
+		err = r.reconcile(ctx, list)
+		if err != nil {
+			return err
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return stream.Error()
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, list []*gosolo.Task) error {
+	byID := map[string]*gosolo.Task{}
+
+	for _, task := range list {
+		byID[task.ID] = task
+	}
+
+	seriesList, err := r.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, series := range seriesList {
+		task := byID[series.TaskID]
+		if task == nil || !task.Complete {
+			continue
+		}
+
+		err = r.advance(ctx, series, task)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) advance(ctx context.Context, series *Series, completed *gosolo.Task) error {
+	rule, err := ParseRRule(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(series.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	prev := completed.After
+	if prev.Before(series.Start) {
+		prev = series.Start
+	}
+
+	next, ok := rule.Next(series.Start.In(loc), prev)
+	if !ok {
+		// Series exhausted by COUNT or UNTIL
+		return r.store.Delete(ctx, series.ID)
+	}
+
+	// Only the reservation holder may create the occurrence, so reconcilers
+	// never race to create it
+	err = r.store.Reserve(ctx, series.ID, completed.ID, r.id, time.Now().Add(reserveTTL))
+	if errors.Is(err, ErrSeriesConflict) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// A reconciler whose reservation expired may have created the
+	// occurrence and then died before advancing the series; adopt it
+	// instead of creating a twin.
+	existing, err := r.client.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Filters: []gosolo.Filter{
+			{Path: "name", Op: "eq", Value: series.Name},
+			{Path: "after", Op: "eq", Value: next.UTC().Format(time.RFC3339Nano)},
+			incompleteFilter(),
+		},
+		Limit: 1,
+	})
+	if err != nil {
+		return err
+	}
+
+	var task *gosolo.Task
+
+	created := false
+
+	if len(existing) > 0 {
+		task = existing[0]
+	} else {
+		task, err = r.client.CreateTask(ctx, &gosolo.Task{
+			Name:  series.Name,
+			After: next,
+		})
+		if err != nil {
+			return err
+		}
+
+		created = true
+	}
+
+	err = r.store.Advance(ctx, series.ID, completed.ID, task.ID)
+
+	switch {
+	case err == nil:
+		return nil
+
+	case errors.Is(err, ErrSeriesConflict) && created:
+		// Our reservation expired and another reconciler advanced the
+		// series past our occurrence. Unless it was adopted (and since
+		// completed), it's a twin of theirs.
+		current, err := r.client.GetTask(ctx, task.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		if current.Complete {
+			return nil
+		}
+
+		return r.client.DeleteTask(ctx, task.ID, &gosolo.UpdateOpts[gosolo.Task]{Prev: current})
+
+	case errors.Is(err, ErrSeriesConflict):
+		return nil
+
+	default:
+		return err
+	}
+}
+
+func newSeriesID() string {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
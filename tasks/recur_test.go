@@ -0,0 +1,161 @@
+package tasks_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+	"github.com/tasksolo/gosolo/tasks"
+)
+
+func TestReconcilersDoNotDoubleCreate(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	// Widen the window between deciding to create and advancing
+	srv.Hook = func(r *http.Request) error {
+		if r.Method == http.MethodPost {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		return nil
+	}
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "series.json")
+	c := gosolo.NewClientDirect(srv.URL)
+
+	series, first, err := tasks.NewReconciler(c, tasks.NewFileSeriesStore(path)).
+		CreateSeries(ctx, "water plants", "FREQ=DAILY", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.UpdateTask(ctx, first.ID, &gosolo.Task{Complete: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Separate stores on one file, like separate processes
+			r := tasks.NewReconciler(c, tasks.NewFileSeriesStore(path))
+
+			err := r.ReconcileOnce(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := srv.Count(http.MethodPost, "task"); n != 2 {
+		t.Errorf("%d tasks created, want 2", n)
+	}
+
+	if n := srv.Count(http.MethodDelete, "task"); n != 0 {
+		t.Errorf("%d tasks deleted, want 0", n)
+	}
+
+	list, err := tasks.NewFileSeriesStore(path).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0].ID != series.ID || list[0].TaskID == first.ID || list[0].ReservedBy != "" {
+		t.Errorf("series after reconcile: %+v", list[0])
+	}
+
+	next := srv.Get("task", list[0].TaskID)
+	if next == nil || next["after"] != "2026-01-02T09:00:00Z" {
+		t.Errorf("next occurrence: %v", next)
+	}
+
+	// A reconciler whose reservation expires after it creates the next
+	// occurrence, and which only advances once another reconciler has
+	// adopted that occurrence (and, the second time, completed it and
+	// moved on), must leave it alone
+	for i, completeAdopted := range []bool{false, true} {
+		_, err = c.UpdateTask(ctx, list[0].TaskID, &gosolo.Task{Complete: true}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slow := &expiringStore{
+			FileSeriesStore: tasks.NewFileSeriesStore(path),
+			beforeAdvance: func(toTaskID string) {
+				err := tasks.NewReconciler(c, tasks.NewFileSeriesStore(path)).ReconcileOnce(ctx)
+				if err != nil {
+					t.Error(err)
+				}
+
+				if !completeAdopted {
+					return
+				}
+
+				_, err = c.UpdateTask(ctx, toTaskID, &gosolo.Task{Complete: true}, nil)
+				if err != nil {
+					t.Error(err)
+				}
+
+				err = tasks.NewReconciler(c, tasks.NewFileSeriesStore(path)).ReconcileOnce(ctx)
+				if err != nil {
+					t.Error(err)
+				}
+			},
+		}
+
+		err = tasks.NewReconciler(c, slow).ReconcileOnce(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n := srv.Count(http.MethodDelete, "task"); n != 0 {
+			t.Errorf("case %d: %d tasks deleted, want 0", i, n)
+		}
+
+		list, err = tasks.NewFileSeriesStore(path).List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cur := srv.Get("task", list[0].TaskID); cur == nil || cur["complete"] == true {
+			t.Errorf("case %d: series points at %s: %v", i, list[0].TaskID, cur)
+		}
+	}
+
+	if n := srv.Count(http.MethodPost, "task"); n != 5 {
+		t.Errorf("%d tasks created, want 5", n)
+	}
+}
+
+// expiringStore reserves with an already expired reservation and calls
+// beforeAdvance before each Advance, like a reconciler that stalled
+type expiringStore struct {
+	*tasks.FileSeriesStore
+
+	beforeAdvance func(toTaskID string)
+}
+
+func (es *expiringStore) Reserve(ctx context.Context, id, fromTaskID, holder string, _ time.Time) error {
+	return es.FileSeriesStore.Reserve(ctx, id, fromTaskID, holder, time.Now())
+}
+
+func (es *expiringStore) Advance(ctx context.Context, id, fromTaskID, toTaskID string) error {
+	es.beforeAdvance(toTaskID)
+	return es.FileSeriesStore.Advance(ctx, id, fromTaskID, toTaskID)
+}
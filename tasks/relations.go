@@ -0,0 +1,366 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+type RelationKind string
+
+const (
+	// From is a subtask of To; To can't be done until From is
+	RelationSubtask RelationKind = "subtask"
+
+	// From is blocked by To; From can't be done until To is
+	RelationBlockedBy RelationKind = "blockedBy"
+)
+
+type Relation struct {
+	Kind RelationKind `json:"kind"`
+	From string       `json:"from"`
+	To   string       `json:"to"`
+}
+
+// RelationStore persists relations, which the Task resource has no fields
+// for. Add calls check with the stored relations and adds rel only if it
+// returns nil, with no other Add in between, so concurrent links can't
+// form a cycle together.
+type RelationStore interface {
+	List(ctx context.Context) ([]*Relation, error)
+	Add(ctx context.Context, rel *Relation, check func([]*Relation) error) error
+	Remove(ctx context.Context, rel *Relation) error
+}
+
+type Relations struct {
+	client *gosolo.Client
+	store  RelationStore
+}
+
+// Graph is a snapshot of tasks and the dependencies between them. A task
+// depends on its blockers and on its subtasks.
+type Graph struct {
+	Tasks     map[string]*gosolo.Task
+	Relations []*Relation
+
+	deps       map[string][]string // task ID -> IDs it depends on
+	dependents map[string][]string // task ID -> IDs depending on it
+}
+
+var (
+	ErrCycle           = fmt.Errorf("dependency cycle")
+	ErrInvalidRelation = fmt.Errorf("invalid relation")
+)
+
+func NewRelations(c *gosolo.Client, store RelationStore) *Relations {
+	return &Relations{
+		client: c,
+		store:  store,
+	}
+}
+
+func (r *Relations) AddSubtask(ctx context.Context, parentID, childID string) error {
+	return r.link(ctx, &Relation{Kind: RelationSubtask, From: childID, To: parentID})
+}
+
+func (r *Relations) AddBlocker(ctx context.Context, taskID, blockerID string) error {
+	return r.link(ctx, &Relation{Kind: RelationBlockedBy, From: taskID, To: blockerID})
+}
+
+func (r *Relations) Unlink(ctx context.Context, rel *Relation) error {
+	return r.store.Remove(ctx, rel)
+}
+
+// Graph fetches the task list and relations. Relations to tasks that no
+// longer exist are left out; Prune deletes them.
+func (r *Relations) Graph(ctx context.Context) (*Graph, error) {
+	list, err := r.client.ListTask(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rels, err := r.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGraph(list, rels), nil
+}
+
+// Prune removes relations to deleted tasks.
+func (r *Relations) Prune(ctx context.Context) error {
+	graph, err := r.Graph(ctx)
+	if err != nil {
+		return err
+	}
+
+	rels, err := r.store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range rels {
+		if graph.Tasks[rel.From] != nil && graph.Tasks[rel.To] != nil {
+			continue
+		}
+
+		err = r.store.Remove(ctx, rel)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Relations) link(ctx context.Context, rel *Relation) error {
+	if rel.From == rel.To {
+		return fmt.Errorf("%s %s itself (%w)", rel.From, rel.Kind, ErrInvalidRelation)
+	}
+
+	list, err := r.client.ListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	return r.store.Add(ctx, rel, func(rels []*Relation) error {
+		graph := NewGraph(list, rels)
+
+		for _, id := range []string{rel.From, rel.To} {
+			if graph.Tasks[id] == nil {
+				return fmt.Errorf("%s (%w)", id, gosolo.ErrNotFound)
+			}
+		}
+
+		dependent, dependency := rel.edge()
+
+		if graph.dependsOn(dependency, dependent) {
+			return fmt.Errorf("%s %s %s (%w)", rel.From, rel.Kind, rel.To, ErrCycle)
+		}
+
+		return nil
+	})
+}
+
+func NewGraph(list []*gosolo.Task, rels []*Relation) *Graph {
+	g := &Graph{
+		Tasks:      map[string]*gosolo.Task{},
+		Relations:  []*Relation{},
+		deps:       map[string][]string{},
+		dependents: map[string][]string{},
+	}
+
+	for _, task := range list {
+		g.Tasks[task.ID] = task
+	}
+
+	for _, rel := range rels {
+		if g.Tasks[rel.From] == nil || g.Tasks[rel.To] == nil {
+			continue
+		}
+
+		g.Relations = append(g.Relations, rel)
+
+		dependent, dependency := rel.edge()
+		g.deps[dependent] = append(g.deps[dependent], dependency)
+		g.dependents[dependency] = append(g.dependents[dependency], dependent)
+	}
+
+	return g
+}
+
+func (g *Graph) Subtasks(id string) []*gosolo.Task {
+	return g.related(id, RelationSubtask, false)
+}
+
+func (g *Graph) Parents(id string) []*gosolo.Task {
+	return g.related(id, RelationSubtask, true)
+}
+
+func (g *Graph) Blockers(id string) []*gosolo.Task {
+	return g.related(id, RelationBlockedBy, true)
+}
+
+// Blocking returns the tasks that id blocks.
+func (g *Graph) Blocking(id string) []*gosolo.Task {
+	return g.related(id, RelationBlockedBy, false)
+}
+
+// Waiting returns the incomplete tasks id depends on, directly or through
+// other tasks.
+func (g *Graph) Waiting(id string) []*gosolo.Task {
+	ret := []*gosolo.Task{}
+	seen := map[string]bool{id: true}
+	queue := append([]string{}, g.deps[id]...)
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if seen[cur] {
+			continue
+		}
+
+		seen[cur] = true
+
+		if !g.Tasks[cur].Complete {
+			ret = append(ret, g.Tasks[cur])
+		}
+
+		queue = append(queue, g.deps[cur]...)
+	}
+
+	sortByID(ret)
+
+	return ret
+}
+
+// Actionable returns incomplete tasks whose After has passed (or was never
+// set) and whose dependencies are all complete, soonest first.
+func (g *Graph) Actionable(now time.Time) []*gosolo.Task {
+	ret := []*gosolo.Task{}
+
+	for id, task := range g.Tasks {
+		if task.Complete || task.After.After(now) {
+			continue
+		}
+
+		blocked := false
+
+		for _, dep := range g.deps[id] {
+			if !g.Tasks[dep].Complete {
+				blocked = true
+				break
+			}
+		}
+
+		if !blocked {
+			ret = append(ret, task)
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if !ret[i].After.Equal(ret[j].After) {
+			return ret[i].After.Before(ret[j].After)
+		}
+
+		return ret[i].ID < ret[j].ID
+	})
+
+	return ret
+}
+
+// Sorted returns every task after the tasks it depends on, or ErrCycle
+// (naming a task on the cycle) if there's no such order, e.g. from relations
+// written to the store directly.
+func (g *Graph) Sorted() ([]*gosolo.Task, error) {
+	remaining := map[string]int{}
+	ready := []string{}
+
+	for id := range g.Tasks {
+		remaining[id] = len(g.deps[id])
+
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	sort.Strings(ready)
+
+	ret := []*gosolo.Task{}
+
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+
+		ret = append(ret, g.Tasks[id])
+
+		next := []string{}
+
+		for _, dependent := range g.dependents[id] {
+			remaining[dependent]--
+
+			if remaining[dependent] == 0 {
+				next = append(next, dependent)
+			}
+		}
+
+		sort.Strings(next)
+		ready = append(ready, next...)
+	}
+
+	if len(ret) < len(g.Tasks) {
+		for id, n := range remaining {
+			if n > 0 {
+				return nil, fmt.Errorf("%s (%w)", id, ErrCycle)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// dependsOn reports whether from depends on to, directly or indirectly.
+func (g *Graph) dependsOn(from, to string) bool {
+	seen := map[string]bool{}
+	stack := []string{from}
+
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if cur == to {
+			return true
+		}
+
+		if seen[cur] {
+			continue
+		}
+
+		seen[cur] = true
+		stack = append(stack, g.deps[cur]...)
+	}
+
+	return false
+}
+
+// related returns To for relations of kind from id if forward, else From
+// for relations of kind to id.
+func (g *Graph) related(id string, kind RelationKind, forward bool) []*gosolo.Task {
+	ret := []*gosolo.Task{}
+
+	for _, rel := range g.Relations {
+		switch {
+		case rel.Kind != kind:
+		case forward && rel.From == id:
+			ret = append(ret, g.Tasks[rel.To])
+		case !forward && rel.To == id:
+			ret = append(ret, g.Tasks[rel.From])
+		}
+	}
+
+	sortByID(ret)
+
+	return ret
+}
+
+// edge returns the relation as a dependency: dependent can't be done until
+// dependency is.
+func (rel *Relation) edge() (string, string) {
+	if rel.Kind == RelationSubtask {
+		return rel.To, rel.From
+	}
+
+	return rel.From, rel.To
+}
+
+func (rel *Relation) key() string {
+	return fmt.Sprintf("%s/%s/%s", rel.Kind, rel.From, rel.To)
+}
+
+func sortByID(list []*gosolo.Task) {
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+}
@@ -0,0 +1,242 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopatchy/metadata"
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+	"github.com/tasksolo/gosolo/tasks"
+)
+
+func TestGraph(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	task := func(id string, complete bool, after time.Time) *gosolo.Task {
+		return &gosolo.Task{Metadata: metadata.Metadata{ID: id}, Name: id, Complete: complete, After: after}
+	}
+
+	subtask := func(child, parent string) *tasks.Relation {
+		return &tasks.Relation{Kind: tasks.RelationSubtask, From: child, To: parent}
+	}
+
+	blockedBy := func(id, blocker string) *tasks.Relation {
+		return &tasks.Relation{Kind: tasks.RelationBlockedBy, From: id, To: blocker}
+	}
+
+	tests := []struct {
+		name string
+		list []*gosolo.Task
+		rels []*tasks.Relation
+
+		actionable []string
+		waitingA   []string
+
+		// nil if Sorted should find a cycle
+		sorted []string
+	}{
+		{
+			name:       "no relations",
+			list:       []*gosolo.Task{task("b", false, time.Time{}), task("a", false, now.Add(-time.Hour)), task("c", false, now.Add(time.Hour))},
+			actionable: []string{"b", "a"},
+			waitingA:   []string{},
+			sorted:     []string{"a", "b", "c"},
+		},
+		{
+			name:       "parent waits for subtasks",
+			list:       []*gosolo.Task{task("a", false, time.Time{}), task("b", false, time.Time{}), task("c", true, time.Time{})},
+			rels:       []*tasks.Relation{subtask("b", "a"), subtask("c", "a")},
+			actionable: []string{"b"},
+			waitingA:   []string{"b"},
+			sorted:     []string{"b", "c", "a"},
+		},
+		{
+			name:       "blockers chain",
+			list:       []*gosolo.Task{task("a", false, time.Time{}), task("b", true, time.Time{}), task("c", false, time.Time{})},
+			rels:       []*tasks.Relation{blockedBy("a", "b"), blockedBy("b", "c")},
+			actionable: []string{"a", "c"},
+			waitingA:   []string{"c"},
+			sorted:     []string{"c", "b", "a"},
+		},
+		{
+			name:       "relations to missing tasks are dropped",
+			list:       []*gosolo.Task{task("a", false, time.Time{})},
+			rels:       []*tasks.Relation{blockedBy("a", "gone"), subtask("gone", "a")},
+			actionable: []string{"a"},
+			waitingA:   []string{},
+			sorted:     []string{"a"},
+		},
+		{
+			name:       "cycle",
+			list:       []*gosolo.Task{task("a", false, time.Time{}), task("b", false, time.Time{}), task("c", false, time.Time{})},
+			rels:       []*tasks.Relation{blockedBy("a", "b"), subtask("a", "b"), blockedBy("c", "a")},
+			actionable: []string{},
+			waitingA:   []string{"b"},
+		},
+	}
+
+	for _, test := range tests {
+		graph := tasks.NewGraph(test.list, test.rels)
+
+		if got := ids(graph.Actionable(now)); !reflect.DeepEqual(got, test.actionable) {
+			t.Errorf("%s: Actionable() = %v, want %v", test.name, got, test.actionable)
+		}
+
+		if got := ids(graph.Waiting("a")); !reflect.DeepEqual(got, test.waitingA) {
+			t.Errorf("%s: Waiting(a) = %v, want %v", test.name, got, test.waitingA)
+		}
+
+		sorted, err := graph.Sorted()
+
+		switch {
+		case test.sorted == nil && !errors.Is(err, tasks.ErrCycle):
+			t.Errorf("%s: Sorted() = %v, %v, want a cycle", test.name, ids(sorted), err)
+		case test.sorted != nil && (err != nil || !reflect.DeepEqual(ids(sorted), test.sorted)):
+			t.Errorf("%s: Sorted() = %v, %v, want %v", test.name, ids(sorted), err, test.sorted)
+		}
+	}
+}
+
+func TestGraphDirections(t *testing.T) {
+	t.Parallel()
+
+	list := []*gosolo.Task{}
+
+	for _, id := range []string{"parent", "child", "blocker"} {
+		list = append(list, &gosolo.Task{Metadata: metadata.Metadata{ID: id}})
+	}
+
+	graph := tasks.NewGraph(list, []*tasks.Relation{
+		{Kind: tasks.RelationSubtask, From: "child", To: "parent"},
+		{Kind: tasks.RelationBlockedBy, From: "child", To: "blocker"},
+	})
+
+	tests := []struct {
+		name string
+		got  []*gosolo.Task
+		want []string
+	}{
+		{"Subtasks(parent)", graph.Subtasks("parent"), []string{"child"}},
+		{"Subtasks(child)", graph.Subtasks("child"), []string{}},
+		{"Parents(child)", graph.Parents("child"), []string{"parent"}},
+		{"Blockers(child)", graph.Blockers("child"), []string{"blocker"}},
+		{"Blocking(blocker)", graph.Blocking("blocker"), []string{"child"}},
+		{"Waiting(parent)", graph.Waiting("parent"), []string{"blocker", "child"}},
+		{"Waiting(blocker)", graph.Waiting("blocker"), []string{}},
+	}
+
+	for _, test := range tests {
+		if got := ids(test.got); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRelations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		srv.Put("task", map[string]any{"id": id, "name": id})
+	}
+
+	c := gosolo.NewClientDirect(srv.URL)
+	path := filepath.Join(t.TempDir(), "relations.json")
+	store := tasks.NewFileRelationStore(path)
+	rels := tasks.NewRelations(c, store)
+
+	err := rels.AddSubtask(ctx, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a depends on its subtask b, so b can't also wait for a
+	err = rels.AddBlocker(ctx, "b", "a")
+	if !errors.Is(err, tasks.ErrCycle) {
+		t.Errorf("AddBlocker(b, a) = %v", err)
+	}
+
+	err = rels.AddBlocker(ctx, "a", "a")
+	if !errors.Is(err, tasks.ErrInvalidRelation) {
+		t.Errorf("AddBlocker(a, a) = %v", err)
+	}
+
+	err = rels.AddBlocker(ctx, "a", "missing")
+	if !errors.Is(err, gosolo.ErrNotFound) {
+		t.Errorf("AddBlocker(a, missing) = %v", err)
+	}
+
+	// Opposite links from separate processes: only one may land
+	errs := make([]error, 2)
+	wg := sync.WaitGroup{}
+
+	for i, pair := range [][2]string{{"c", "d"}, {"d", "c"}} {
+		i, pair := i, pair
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			other := tasks.NewRelations(c, tasks.NewFileRelationStore(path))
+			errs[i] = other.AddBlocker(ctx, pair[0], pair[1])
+		}()
+	}
+
+	wg.Wait()
+
+	if (errs[0] == nil) == (errs[1] == nil) {
+		t.Errorf("opposite links: %v, %v", errs[0], errs[1])
+	}
+
+	graph, err := rels.Graph(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = graph.Sorted()
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = c.DeleteTask(ctx, "b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rels.Prune(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 1 || list[0].Kind != tasks.RelationBlockedBy {
+		t.Errorf("after Prune: %v", list)
+	}
+}
+
+func ids(list []*gosolo.Task) []string {
+	ret := []string{}
+
+	for _, task := range list {
+		ret = append(ret, task.ID)
+	}
+
+	return ret
+}
@@ -0,0 +1,666 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency int
+
+const (
+	Minutely Frequency = iota
+	Hourly
+	Daily
+	Weekly
+	Monthly
+	Yearly
+)
+
+// RRule is the subset of an RFC 5545 recurrence rule that makes sense for
+// tasks: FREQ (MINUTELY through YEARLY), INTERVAL, COUNT, UNTIL, BYMONTH,
+// BYMONTHDAY, BYDAY (with ordinals for MONTHLY/YEARLY), BYHOUR, BYMINUTE,
+// BYSETPOS and WKST.
+type RRule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	Until    time.Time
+
+	ByMonth    []int
+	ByMonthDay []int
+	ByDay      []WeekdayNum
+	ByHour     []int
+	ByMinute   []int
+	BySetPos   []int
+
+	WeekStart time.Weekday
+}
+
+// WeekdayNum is a BYDAY entry; N is the optional ordinal ("-1FR" is N=-1)
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+var ErrInvalidRRule = fmt.Errorf("invalid RRULE")
+
+var freqNames = map[string]Frequency{
+	"MINUTELY": Minutely,
+	"HOURLY":   Hourly,
+	"DAILY":    Daily,
+	"WEEKLY":   Weekly,
+	"MONTHLY":  Monthly,
+	"YEARLY":   Yearly,
+}
+
+var dayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Stop expanding after this many consecutive periods without an occurrence
+const maxEmptyPeriods = 10000
+
+func ParseRRule(s string) (*RRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+
+	r := &RRule{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+
+	hasFreq := false
+
+	for _, part := range strings.Split(s, ";") {
+		key, val, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("%s (%w)", part, ErrInvalidRRule)
+		}
+
+		var err error
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq, ok := freqNames[strings.ToUpper(val)]
+			if !ok {
+				return nil, fmt.Errorf("FREQ=%s (%w)", val, ErrInvalidRRule)
+			}
+
+			r.Freq = freq
+			hasFreq = true
+
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(val)
+			if err == nil && r.Interval < 1 {
+				err = ErrInvalidRRule
+			}
+
+		case "COUNT":
+			r.Count, err = strconv.Atoi(val)
+
+		case "UNTIL":
+			r.Until, err = parseICalTime(val)
+
+		case "BYMONTH":
+			r.ByMonth, err = parseIntList(val, 1, 12, false)
+
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(val, 1, 31, true)
+
+		case "BYDAY":
+			r.ByDay, err = parseWeekdayList(val)
+
+		case "BYHOUR":
+			r.ByHour, err = parseIntList(val, 0, 23, false)
+
+		case "BYMINUTE":
+			r.ByMinute, err = parseIntList(val, 0, 59, false)
+
+		case "BYSETPOS":
+			r.BySetPos, err = parseIntList(val, 1, 366, true)
+
+		case "WKST":
+			var wd WeekdayNum
+
+			wd, err = parseWeekday(val)
+			r.WeekStart = wd.Weekday
+
+		default:
+			err = fmt.Errorf("unsupported part %s", key) //nolint:goerr113
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s (%w)", part, err, ErrInvalidRRule) //nolint:errorlint
+		}
+	}
+
+	if !hasFreq {
+		return nil, fmt.Errorf("missing FREQ (%w)", ErrInvalidRRule)
+	}
+
+	return r, nil
+}
+
+func (r *RRule) String() string {
+	parts := []string{}
+
+	for name, freq := range freqNames {
+		if freq == r.Freq {
+			parts = append(parts, "FREQ="+name)
+		}
+	}
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(iCalUTCLayout))
+	}
+
+	addInts := func(name string, vals []int) {
+		if len(vals) == 0 {
+			return
+		}
+
+		strs := []string{}
+		for _, v := range vals {
+			strs = append(strs, strconv.Itoa(v))
+		}
+
+		parts = append(parts, name+"="+strings.Join(strs, ","))
+	}
+
+	addInts("BYMONTH", r.ByMonth)
+	addInts("BYMONTHDAY", r.ByMonthDay)
+
+	if len(r.ByDay) > 0 {
+		strs := []string{}
+
+		for _, wd := range r.ByDay {
+			if wd.N != 0 {
+				strs = append(strs, fmt.Sprintf("%d%s", wd.N, dayNames[wd.Weekday]))
+			} else {
+				strs = append(strs, dayNames[wd.Weekday])
+			}
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(strs, ","))
+	}
+
+	addInts("BYHOUR", r.ByHour)
+	addInts("BYMINUTE", r.ByMinute)
+	addInts("BYSETPOS", r.BySetPos)
+
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+dayNames[r.WeekStart])
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence of the series starting at dtstart that
+// is strictly after after. The second return is false once the series is
+// exhausted by COUNT or UNTIL.
+func (r *RRule) Next(dtstart, after time.Time) (time.Time, bool) {
+	count := 0
+	empty := 0
+
+	for period := r.firstPeriod(dtstart, after); empty < maxEmptyPeriods; period++ {
+		cands := r.expand(dtstart, period)
+
+		if len(cands) == 0 {
+			empty++
+			continue
+		}
+
+		empty = 0
+
+		for _, cand := range cands {
+			if cand.Before(dtstart) {
+				continue
+			}
+
+			if !r.Until.IsZero() && cand.After(r.Until) {
+				return time.Time{}, false
+			}
+
+			count++
+
+			if r.Count > 0 && count > r.Count {
+				return time.Time{}, false
+			}
+
+			if cand.After(after) {
+				return cand, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// firstPeriod returns a period no later than the one holding the first
+// occurrence after after, so Next needn't replay the series from dtstart.
+// COUNT has to see every earlier occurrence, so it starts from 0.
+func (r *RRule) firstPeriod(dtstart, after time.Time) int {
+	if r.Count > 0 || !after.After(dtstart) {
+		return 0
+	}
+
+	var n int
+
+	switch r.Freq {
+	case Minutely:
+		n = int(after.Sub(dtstart) / time.Minute)
+	case Hourly:
+		n = int(after.Sub(dtstart) / time.Hour)
+	case Daily:
+		n = int(after.Sub(dtstart) / (24 * time.Hour))
+	case Weekly:
+		n = int(after.Sub(dtstart) / (7 * 24 * time.Hour))
+	case Monthly:
+		n = (after.Year()-dtstart.Year())*12 + int(after.Month()) - int(dtstart.Month())
+	case Yearly:
+		n = after.Year() - dtstart.Year()
+	}
+
+	// One period back covers DST shifts in the duration based ones
+	n = n/r.Interval - 1
+	if n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+// expand returns the sorted occurrences in the nth period after dtstart
+func (r *RRule) expand(dtstart time.Time, n int) []time.Time {
+	step := n * r.Interval
+	loc := dtstart.Location()
+	days := []time.Time{}
+
+	switch r.Freq {
+	case Yearly:
+		year := dtstart.Year() + step
+
+		switch {
+		case len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) > 0:
+			// Ordinals count within the year
+			first := localDate(year, 1, 1, 0, 0, 0, loc)
+			days = r.matchWeekdays(first, first.AddDate(1, 0, 0))
+
+		default:
+			months := r.ByMonth
+			if len(months) == 0 {
+				if len(r.ByMonthDay) > 0 || len(r.ByDay) > 0 {
+					months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+				} else {
+					months = []int{int(dtstart.Month())}
+				}
+			}
+
+			for _, m := range months {
+				days = append(days, r.monthDays(dtstart, year, time.Month(m))...)
+			}
+		}
+
+	case Monthly:
+		first := localDate(dtstart.Year(), dtstart.Month()+time.Month(step), 1, 0, 0, 0, loc)
+		if r.monthOK(first.Month()) {
+			days = r.monthDays(dtstart, first.Year(), first.Month())
+		}
+
+	case Weekly:
+		base := localDate(dtstart.Year(), dtstart.Month(), dtstart.Day()+7*step, 0, 0, 0, loc)
+		offset := (int(base.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := base.AddDate(0, 0, -offset)
+
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+
+			if !r.monthOK(day.Month()) {
+				continue
+			}
+
+			if len(r.ByDay) > 0 {
+				if r.weekdayOK(day.Weekday()) {
+					days = append(days, day)
+				}
+			} else if day.Weekday() == dtstart.Weekday() {
+				days = append(days, day)
+			}
+		}
+
+	case Daily:
+		day := localDate(dtstart.Year(), dtstart.Month(), dtstart.Day()+step, 0, 0, 0, loc)
+		if r.dayOK(day) {
+			days = append(days, day)
+		}
+
+	case Hourly:
+		cand := dtstart.Add(time.Duration(step) * time.Hour)
+		if !r.dayOK(cand) || !r.hourOK(cand.Hour()) {
+			return nil
+		}
+
+		minutes := r.ByMinute
+		if len(minutes) == 0 {
+			minutes = []int{cand.Minute()}
+		}
+
+		ret := []time.Time{}
+
+		for _, m := range minutes {
+			ret = append(ret, localDate(cand.Year(), cand.Month(), cand.Day(), cand.Hour(), m, cand.Second(), cand.Location()))
+		}
+
+		return r.setPos(sortTimes(ret))
+
+	case Minutely:
+		cand := dtstart.Add(time.Duration(step) * time.Minute)
+		if !r.dayOK(cand) || !r.hourOK(cand.Hour()) || (len(r.ByMinute) > 0 && !containsInt(r.ByMinute, cand.Minute())) {
+			return nil
+		}
+
+		return r.setPos([]time.Time{cand})
+	}
+
+	ret := []time.Time{}
+
+	for _, day := range days {
+		ret = append(ret, r.atTimes(dtstart, day)...)
+	}
+
+	return r.setPos(sortTimes(ret))
+}
+
+func (r *RRule) monthDays(dtstart time.Time, year int, month time.Month) []time.Time {
+	loc := dtstart.Location()
+	first := localDate(year, month, 1, 0, 0, 0, loc)
+	next := first.AddDate(0, 1, 0)
+	numDays := next.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if dtstart.Day() > numDays {
+			// e.g. the 31st in a 30 day month is skipped
+			return nil
+		}
+
+		return []time.Time{localDate(year, month, dtstart.Day(), 0, 0, 0, loc)}
+	}
+
+	var days []time.Time
+
+	if len(r.ByDay) > 0 {
+		days = r.matchWeekdays(first, next)
+	} else {
+		for d := 1; d <= numDays; d++ {
+			days = append(days, localDate(year, month, d, 0, 0, 0, loc))
+		}
+	}
+
+	if len(r.ByMonthDay) == 0 {
+		return days
+	}
+
+	ret := []time.Time{}
+
+	for _, day := range days {
+		for _, md := range r.ByMonthDay {
+			if md == day.Day() || (md < 0 && numDays+md+1 == day.Day()) {
+				ret = append(ret, day)
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// matchWeekdays returns days in [start, end) matching BYDAY, with ordinals
+// counted within that range
+func (r *RRule) matchWeekdays(start, end time.Time) []time.Time {
+	byWeekday := map[time.Weekday][]time.Time{}
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		byWeekday[day.Weekday()] = append(byWeekday[day.Weekday()], day)
+	}
+
+	ret := []time.Time{}
+
+	for _, wd := range r.ByDay {
+		matches := byWeekday[wd.Weekday]
+
+		switch {
+		case wd.N == 0:
+			ret = append(ret, matches...)
+
+		case wd.N > 0 && wd.N <= len(matches):
+			ret = append(ret, matches[wd.N-1])
+
+		case wd.N < 0 && -wd.N <= len(matches):
+			ret = append(ret, matches[len(matches)+wd.N])
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Before(ret[j]) })
+
+	return ret
+}
+
+func (r *RRule) atTimes(dtstart, day time.Time) []time.Time {
+	hours := r.ByHour
+	if len(hours) == 0 {
+		hours = []int{dtstart.Hour()}
+	}
+
+	minutes := r.ByMinute
+	if len(minutes) == 0 {
+		minutes = []int{dtstart.Minute()}
+	}
+
+	ret := []time.Time{}
+
+	for _, h := range hours {
+		for _, m := range minutes {
+			ret = append(ret, localDate(day.Year(), day.Month(), day.Day(), h, m, dtstart.Second(), day.Location()))
+		}
+	}
+
+	return ret
+}
+
+func (r *RRule) setPos(cands []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return cands
+	}
+
+	ret := []time.Time{}
+
+	for _, pos := range r.BySetPos {
+		switch {
+		case pos > 0 && pos <= len(cands):
+			ret = append(ret, cands[pos-1])
+		case pos < 0 && -pos <= len(cands):
+			ret = append(ret, cands[len(cands)+pos])
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Before(ret[j]) })
+
+	return ret
+}
+
+func (r *RRule) dayOK(t time.Time) bool {
+	if !r.monthOK(t.Month()) || !r.weekdayOK(t.Weekday()) {
+		return false
+	}
+
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	numDays := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+
+	for _, md := range r.ByMonthDay {
+		if md == t.Day() || (md < 0 && numDays+md+1 == t.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *RRule) hourOK(h int) bool {
+	return len(r.ByHour) == 0 || containsInt(r.ByHour, h)
+}
+
+func (r *RRule) monthOK(m time.Month) bool {
+	return len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(m))
+}
+
+func (r *RRule) weekdayOK(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+
+	for _, bd := range r.ByDay {
+		if bd.Weekday == wd {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseIntList(s string, min, max int, allowNegative bool) ([]int, error) {
+	ret := []int{}
+
+	for _, str := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(str)
+		if err != nil {
+			return nil, err
+		}
+
+		abs := v
+		if abs < 0 && allowNegative {
+			abs = -abs
+		}
+
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("%d out of range", v) //nolint:goerr113
+		}
+
+		ret = append(ret, v)
+	}
+
+	return ret, nil
+}
+
+func parseWeekdayList(s string) ([]WeekdayNum, error) {
+	ret := []WeekdayNum{}
+
+	for _, str := range strings.Split(s, ",") {
+		wd, err := parseWeekday(str)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, wd)
+	}
+
+	return ret, nil
+}
+
+func parseWeekday(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(s)
+
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid weekday %s", s) //nolint:goerr113
+	}
+
+	name := s[len(s)-2:]
+	wd := WeekdayNum{}
+
+	if len(s) > 2 {
+		n, err := strconv.Atoi(s[:len(s)-2])
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("invalid weekday %s", s) //nolint:goerr113
+		}
+
+		wd.N = n
+	}
+
+	for i, dn := range dayNames {
+		if dn == name {
+			wd.Weekday = time.Weekday(i)
+			return wd, nil
+		}
+	}
+
+	return WeekdayNum{}, fmt.Errorf("invalid weekday %s", s) //nolint:goerr113
+}
+
+const (
+	iCalUTCLayout   = "20060102T150405Z"
+	iCalLocalLayout = "20060102T150405"
+	iCalDateLayout  = "20060102"
+)
+
+func parseICalTime(s string) (time.Time, error) {
+	for _, layout := range []string{iCalUTCLayout, iCalLocalLayout, iCalDateLayout} {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %s", s) //nolint:goerr113
+}
+
+// localDate is time.Date, except that a wall clock time skipped by a DST
+// gap takes the UTC offset from before the gap (RFC 5545, 3.3.5), so 02:30
+// on a day that jumps from 02:00 to 03:00 is 03:30.
+func localDate(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+	wall := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+
+	if t.Day() == wall.Day() && t.Hour() == wall.Hour() && t.Minute() == wall.Minute() {
+		return t
+	}
+
+	_, offset := t.Add(-24 * time.Hour).Zone()
+
+	return wall.Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// sortTimes sorts ts and drops duplicates, which DST gaps can create by
+// moving a skipped hour onto the next one.
+func sortTimes(ts []time.Time) []time.Time {
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+
+	ret := ts[:0]
+
+	for _, t := range ts {
+		if len(ret) == 0 || !t.Equal(ret[len(ret)-1]) {
+			ret = append(ret, t)
+		}
+	}
+
+	return ret
+}
+
+func containsInt(list []int, v int) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,179 @@
+package tasks_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo/tasks"
+)
+
+func TestRRuleNext(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	dtstart := time.Date(2026, 1, 31, 9, 15, 0, 0, loc)
+
+	rules := []string{
+		"FREQ=MINUTELY;INTERVAL=7",
+		"FREQ=HOURLY;INTERVAL=5;BYMINUTE=0,30",
+		"FREQ=DAILY;INTERVAL=3",
+		"FREQ=DAILY;BYDAY=MO,WE,FR;BYHOUR=8,17",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,SA",
+		"FREQ=MONTHLY",
+		"FREQ=MONTHLY;BYDAY=-1FR",
+		"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+	}
+
+	afters := []time.Time{
+		dtstart.Add(-time.Hour),
+		dtstart,
+		dtstart.Add(90 * time.Minute),
+		time.Date(2026, 3, 8, 2, 30, 0, 0, loc),
+		time.Date(2026, 11, 1, 1, 30, 0, 0, loc),
+		time.Date(2029, 6, 15, 12, 0, 0, 0, loc),
+	}
+
+	for _, str := range rules {
+		fast, err := tasks.ParseRRule(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A COUNT that never ends the series forces a replay from dtstart
+		slow, err := tasks.ParseRRule(str + ";COUNT=1000000000")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, after := range afters {
+			got, gotOK := fast.Next(dtstart, after)
+			want, wantOK := slow.Next(dtstart, after)
+
+			if gotOK != wantOK || !got.Equal(want) {
+				t.Errorf("%s after %s: got %s %t, want %s %t", str, after, got, gotOK, want, wantOK)
+			}
+		}
+	}
+}
+
+func TestRRuleExamples(t *testing.T) {
+	t.Parallel()
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	const layout = "20060102T150405 MST"
+
+	tests := []struct {
+		dtstart string
+		rule    string
+		want    []string
+	}{
+		// RFC 5545, 3.8.5.3
+		{"19970902T090000", "FREQ=DAILY;COUNT=10", []string{
+			"19970902T090000 EDT", "19970903T090000 EDT", "19970904T090000 EDT", "19970905T090000 EDT", "19970906T090000 EDT",
+			"19970907T090000 EDT", "19970908T090000 EDT", "19970909T090000 EDT", "19970910T090000 EDT", "19970911T090000 EDT",
+		}},
+		{"19970902T090000", "FREQ=WEEKLY;UNTIL=19971007T000000Z;WKST=SU;BYDAY=TU,TH", []string{
+			"19970902T090000 EDT", "19970904T090000 EDT", "19970909T090000 EDT", "19970911T090000 EDT", "19970916T090000 EDT",
+			"19970918T090000 EDT", "19970923T090000 EDT", "19970925T090000 EDT", "19970930T090000 EDT", "19971002T090000 EDT",
+		}},
+		{"19970805T090000", "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO", []string{
+			"19970805T090000 EDT", "19970810T090000 EDT", "19970819T090000 EDT", "19970824T090000 EDT",
+		}},
+		{"19970805T090000", "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU", []string{
+			"19970805T090000 EDT", "19970817T090000 EDT", "19970819T090000 EDT", "19970831T090000 EDT",
+		}},
+		{"19970905T090000", "FREQ=MONTHLY;COUNT=10;BYDAY=1FR", []string{
+			"19970905T090000 EDT", "19971003T090000 EDT", "19971107T090000 EST", "19971205T090000 EST", "19980102T090000 EST",
+			"19980206T090000 EST", "19980306T090000 EST", "19980403T090000 EST", "19980501T090000 EDT", "19980605T090000 EDT",
+		}},
+		{"19970922T090000", "FREQ=MONTHLY;COUNT=6;BYDAY=-2MO", []string{
+			"19970922T090000 EDT", "19971020T090000 EDT", "19971117T090000 EST",
+			"19971222T090000 EST", "19980119T090000 EST", "19980216T090000 EST",
+		}},
+		{"19970929T090000", "FREQ=MONTHLY;COUNT=7;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", []string{
+			"19970930T090000 EDT", "19971031T090000 EST", "19971128T090000 EST", "19971231T090000 EST",
+			"19980130T090000 EST", "19980227T090000 EST", "19980331T090000 EST",
+		}},
+		{"19970902T090000", "FREQ=MONTHLY;COUNT=5;BYDAY=FR;BYMONTHDAY=13", []string{
+			"19980213T090000 EST", "19980313T090000 EST", "19981113T090000 EST", "19990813T090000 EDT", "20001013T090000 EDT",
+		}},
+		{"20070115T090000", "FREQ=MONTHLY;COUNT=5;BYMONTHDAY=15,30", []string{
+			"20070115T090000 EST", "20070130T090000 EST", "20070215T090000 EST", "20070315T090000 EDT", "20070330T090000 EDT",
+		}},
+		{"19970519T090000", "FREQ=YEARLY;COUNT=3;BYDAY=20MO", []string{
+			"19970519T090000 EDT", "19980518T090000 EDT", "19990517T090000 EDT",
+		}},
+		{"19970902T090000", "FREQ=MINUTELY;INTERVAL=15;COUNT=6", []string{
+			"19970902T090000 EDT", "19970902T091500 EDT", "19970902T093000 EDT",
+			"19970902T094500 EDT", "19970902T100000 EDT", "19970902T101500 EDT",
+		}},
+		// 02:30 doesn't exist on 2026-03-08, so it takes the EST offset
+		{"20260307T023000", "FREQ=DAILY;COUNT=3", []string{
+			"20260307T023000 EST", "20260308T033000 EDT", "20260309T023000 EDT",
+		}},
+		{"20260301T023000", "FREQ=WEEKLY;COUNT=3", []string{
+			"20260301T023000 EST", "20260308T033000 EDT", "20260315T023000 EDT",
+		}},
+		{"20260307T000000", "FREQ=DAILY;COUNT=3;BYHOUR=1,2,3", []string{
+			"20260307T010000 EST", "20260307T020000 EST", "20260307T030000 EST",
+		}},
+		{"20260308T000000", "FREQ=DAILY;COUNT=3;BYHOUR=1,2,3", []string{
+			"20260308T010000 EST", "20260308T030000 EDT", "20260309T010000 EDT",
+		}},
+		// 01:30 happens twice on 2026-11-01; the first one counts
+		{"20261031T013000", "FREQ=DAILY;COUNT=3", []string{
+			"20261031T013000 EDT", "20261101T013000 EDT", "20261102T013000 EST",
+		}},
+	}
+
+	for _, test := range tests {
+		rule, err := tasks.ParseRRule(test.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dtstart, err := time.ParseInLocation("20060102T150405", test.dtstart, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := []string{}
+
+		for after := dtstart.Add(-time.Second); len(got) <= len(test.want); {
+			next, ok := rule.Next(dtstart, after)
+			if !ok {
+				break
+			}
+
+			got = append(got, next.Format(layout))
+			after = next
+		}
+
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%s from %s:\n got %v\nwant %v", test.rule, test.dtstart, got, test.want)
+		}
+	}
+}
+
+func BenchmarkRRuleNextMinutely(b *testing.B) {
+	rule, err := tasks.ParseRRule("FREQ=MINUTELY")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	dtstart := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := dtstart.AddDate(5, 0, 0)
+
+	for i := 0; i < b.N; i++ {
+		rule.Next(dtstart, after)
+	}
+}
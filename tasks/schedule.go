@@ -0,0 +1,68 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+// ActionableTasks returns incomplete tasks whose After has passed (or was
+// never set), soonest first.
+func ActionableTasks(ctx context.Context, c *gosolo.Client, now time.Time) ([]*gosolo.Task, error) {
+	return c.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Filters: []gosolo.Filter{
+			incompleteFilter(),
+			afterFilter("lte", now),
+		},
+		Sorts: []string{"after"},
+	})
+}
+
+// SnoozedTasks returns incomplete tasks whose After is still in the future.
+func SnoozedTasks(ctx context.Context, c *gosolo.Client, now time.Time) ([]*gosolo.Task, error) {
+	return c.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Filters: []gosolo.Filter{
+			incompleteFilter(),
+			afterFilter("gt", now),
+		},
+		Sorts: []string{"after"},
+	})
+}
+
+// UpcomingTasks returns incomplete tasks that become actionable within window
+// of now.
+func UpcomingTasks(ctx context.Context, c *gosolo.Client, now time.Time, window time.Duration) ([]*gosolo.Task, error) {
+	return c.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Filters: []gosolo.Filter{
+			incompleteFilter(),
+			afterFilter("gt", now),
+			afterFilter("lte", now.Add(window)),
+		},
+		Sorts: []string{"after"},
+	})
+}
+
+func Snooze(ctx context.Context, c *gosolo.Client, id string, d time.Duration) (*gosolo.Task, error) {
+	return SnoozeUntil(ctx, c, id, time.Now().Add(d))
+}
+
+func SnoozeUntil(ctx context.Context, c *gosolo.Client, id string, until time.Time) (*gosolo.Task, error) {
+	return c.UpdateTask(ctx, id, &gosolo.Task{After: until}, nil)
+}
+
+func incompleteFilter() gosolo.Filter {
+	return gosolo.Filter{
+		Path:  "complete",
+		Op:    "eq",
+		Value: "false",
+	}
+}
+
+func afterFilter(op string, t time.Time) gosolo.Filter {
+	return gosolo.Filter{
+		Path:  "after",
+		Op:    op,
+		Value: t.UTC().Format(time.RFC3339Nano),
+	}
+}
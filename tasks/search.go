@@ -0,0 +1,347 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/tasksolo/gosolo"
+)
+
+type SearchOpts struct {
+	// Also match completed tasks
+	IncludeComplete bool
+
+	// Maximum matches returned; 0 for no limit
+	Limit int
+
+	// Matches scoring below this are dropped; defaults to 0.5
+	MinScore float64
+
+	// Search this instead of fetching the task list
+	Index *Index
+}
+
+type Match struct {
+	Task *gosolo.Task
+
+	// 1 for an exact (case-insensitive) name match, lower for looser ones
+	Score float64
+}
+
+// Index holds a task list for repeated local searches. Follow keeps it
+// current.
+type Index struct {
+	tasks []*gosolo.Task
+	ready bool
+	mu    sync.RWMutex
+}
+
+const defaultMinScore = 0.5
+
+// MinIDPrefix is the shortest query Resolve tries as an ID prefix without
+// the "id:" prefix, so ordinary words don't hit IDs by chance.
+const MinIDPrefix = 6
+
+const idPrefix = "id:"
+
+// Search ranks tasks by how well their names match query. An exact name
+// match on the server is returned directly; otherwise names are scored
+// locally, best first.
+func Search(ctx context.Context, c *gosolo.Client, query string, opts *SearchOpts) ([]*Match, error) {
+	if opts == nil {
+		opts = &SearchOpts{}
+	}
+
+	if opts.Index != nil && opts.Index.Ready() {
+		return opts.Index.Search(query, opts), nil
+	}
+
+	filters := []gosolo.Filter{}
+
+	if !opts.IncludeComplete {
+		filters = append(filters, incompleteFilter())
+	}
+
+	exact, err := c.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Filters: append(filters, gosolo.Filter{Path: "name", Op: "eq", Value: query}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(exact) > 0 {
+		ret := []*Match{}
+
+		for _, task := range exact {
+			ret = append(ret, &Match{Task: task, Score: 1})
+		}
+
+		return limitMatches(ret, opts.Limit), nil
+	}
+
+	list, err := c.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Filters: filters,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return searchList(list, query, opts), nil
+}
+
+// Resolve returns the one task that query identifies. An exact name match
+// wins; otherwise a single word of at least MinIDPrefix characters is tried
+// as an ID prefix, and then the clear best name match is taken. An
+// "id:" prefix (e.g. "id:cafe") only matches IDs, at any length. It returns
+// gosolo.ErrNotFound or gosolo.ErrMultipleFound if there is no one task.
+func Resolve(ctx context.Context, c *gosolo.Client, query string, opts *SearchOpts) (*gosolo.Task, error) {
+	if strings.HasPrefix(query, idPrefix) {
+		return c.FindTask(ctx, strings.TrimPrefix(query, idPrefix))
+	}
+
+	matches, err := Search(ctx, c, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) > 0 && matches[0].Score == 1 {
+		if len(matches) > 1 && matches[1].Score == 1 {
+			return nil, fmt.Errorf("%s (%w)", query, gosolo.ErrMultipleFound)
+		}
+
+		return matches[0].Task, nil
+	}
+
+	if len(query) >= MinIDPrefix && strings.IndexFunc(query, unicode.IsSpace) == -1 {
+		task, err := c.FindTask(ctx, query)
+		if err == nil {
+			return task, nil
+		}
+
+		if !errors.Is(err, gosolo.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("%s (%w)", query, gosolo.ErrNotFound)
+
+	case len(matches) > 1 && matches[0].Score == matches[1].Score:
+		return nil, fmt.Errorf("%s (%w)", query, gosolo.ErrMultipleFound)
+
+	default:
+		return matches[0].Task, nil
+	}
+}
+
+func NewIndex() *Index {
+	return &Index{}
+}
+
+// Update replaces the indexed task list.
+func (idx *Index) Update(list []*gosolo.Task) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.tasks = list
+	idx.ready = true
+}
+
+// Ready reports whether the index has received a task list.
+func (idx *Index) Ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.ready
+}
+
+func (idx *Index) Search(query string, opts *SearchOpts) []*Match {
+	if opts == nil {
+		opts = &SearchOpts{}
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return searchList(idx.tasks, query, opts)
+}
+
+// Follow updates the index from StreamListTask until ctx is done.
+func (idx *Index) Follow(ctx context.Context, c *gosolo.Client) error {
+	stream, err := c.StreamListTask(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer stream.Close()
+
+	for list := range stream.Chan() {
+		idx.Update(list)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return stream.Error()
+}
+
+func searchList(list []*gosolo.Task, query string, opts *SearchOpts) []*Match {
+	minScore := opts.MinScore
+	if minScore <= 0 {
+		minScore = defaultMinScore
+	}
+
+	q := normalize(query)
+	ret := []*Match{}
+
+	for _, task := range list {
+		if task.Complete && !opts.IncludeComplete {
+			continue
+		}
+
+		score := scoreName(q, normalize(task.Name))
+		if score < minScore {
+			continue
+		}
+
+		ret = append(ret, &Match{
+			Task:  task,
+			Score: score,
+		})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+
+		if ret[i].Task.Complete != ret[j].Task.Complete {
+			return !ret[i].Task.Complete
+		}
+
+		return ret[i].Task.Name < ret[j].Task.Name
+	})
+
+	return limitMatches(ret, opts.Limit)
+}
+
+func limitMatches(matches []*Match, limit int) []*Match {
+	if limit > 0 && len(matches) > limit {
+		return matches[:limit]
+	}
+
+	return matches
+}
+
+// scoreName compares normalized strings: whole-name matches score highest,
+// then every query word matching some name word, allowing typos.
+func scoreName(q, name string) float64 {
+	switch {
+	case q == "":
+		return 0
+
+	case q == name:
+		return 1
+
+	case strings.HasPrefix(name, q):
+		return 0.9
+
+	case strings.Contains(name, q):
+		return 0.8
+	}
+
+	nameWords := strings.Fields(name)
+	if len(nameWords) == 0 {
+		return 0
+	}
+
+	total := 0.0
+
+	for _, qw := range strings.Fields(q) {
+		best := 0.0
+
+		for _, nw := range nameWords {
+			best = maxFloat(best, scoreWord(qw, nw))
+		}
+
+		total += best
+	}
+
+	return 0.75 * total / float64(len(strings.Fields(q)))
+}
+
+func scoreWord(q, w string) float64 {
+	switch {
+	case q == w:
+		return 1
+
+	case strings.HasPrefix(w, q):
+		return 0.9
+	}
+
+	qr, wr := []rune(q), []rune(w)
+	longest := len(qr)
+
+	if len(wr) > longest {
+		longest = len(wr)
+	}
+
+	return 1 - float64(levenshtein(qr, wr))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func minInt(vals ...int) int {
+	ret := vals[0]
+
+	for _, val := range vals[1:] {
+		if val < ret {
+			ret = val
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,60 @@
+package tasks_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+	"github.com/tasksolo/gosolo/tasks"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("task", map[string]any{"id": "cafe1234abcd", "name": "x"})
+	srv.Put("task", map[string]any{"id": "beef5678abcd", "name": "cafe"})
+	srv.Put("task", map[string]any{"id": "feed0000abcd", "name": "water plants"})
+	srv.Put("task", map[string]any{"id": "feed1111abcd", "name": "dup"})
+	srv.Put("task", map[string]any{"id": "feed2222abcd", "name": "dup"})
+
+	ctx := context.Background()
+	c := gosolo.NewClientDirect(srv.URL)
+
+	tests := []struct {
+		query string
+		id    string
+		err   error
+	}{
+		// Exact name beats an ID prefix
+		{query: "cafe", id: "beef5678abcd"},
+		{query: "id:cafe", id: "cafe1234abcd"},
+		{query: "cafe1234", id: "cafe1234abcd"},
+		{query: "water", id: "feed0000abcd"},
+		{query: "dup", err: gosolo.ErrMultipleFound},
+		{query: "id:feed", err: gosolo.ErrMultipleFound},
+		{query: "feed", err: gosolo.ErrNotFound},
+		{query: "id:f00d", err: gosolo.ErrNotFound},
+	}
+
+	for _, test := range tests {
+		task, err := tasks.Resolve(ctx, c, test.query, nil)
+
+		switch {
+		case test.err != nil:
+			if !errors.Is(err, test.err) {
+				t.Errorf("%q: got %v, want %v", test.query, err, test.err)
+			}
+
+		case err != nil:
+			t.Errorf("%q: %v", test.query, err)
+
+		case task.ID != test.id:
+			t.Errorf("%q: got %s, want %s", test.query, task.ID, test.id)
+		}
+	}
+}
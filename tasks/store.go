@@ -0,0 +1,263 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FileSeriesStore keeps series in a JSON file. Reserve and Advance hold a
+// lock file while they read, check and rewrite, so reconcilers in separate
+// processes on the same host can share one store. Lock files aren't reliable
+// on network filesystems, so it doesn't serve reconcilers on several hosts.
+type FileSeriesStore struct {
+	file *jsonFile[*Series]
+}
+
+// jsonFile is a map persisted as JSON, guarded by an in-process mutex and a
+// lock file beside it.
+type jsonFile[V any] struct {
+	path string
+	mu   sync.Mutex
+}
+
+const (
+	lockRetry = 10 * time.Millisecond
+	lockStale = 30 * time.Second
+)
+
+var ErrSeriesNotFound = fmt.Errorf("series not found")
+
+func NewFileSeriesStore(path string) *FileSeriesStore {
+	return &FileSeriesStore{
+		file: &jsonFile[*Series]{
+			path: path,
+		},
+	}
+}
+
+func (fss *FileSeriesStore) List(ctx context.Context) ([]*Series, error) {
+	ret := []*Series{}
+
+	err := fss.file.locked(ctx, func(m map[string]*Series) (bool, error) {
+		for _, series := range m {
+			ret = append(ret, series)
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].ID < ret[j].ID })
+
+	return ret, nil
+}
+
+func (fss *FileSeriesStore) Put(ctx context.Context, series *Series) error {
+	return fss.file.locked(ctx, func(m map[string]*Series) (bool, error) {
+		m[series.ID] = series
+		return true, nil
+	})
+}
+
+func (fss *FileSeriesStore) Delete(ctx context.Context, id string) error {
+	return fss.file.locked(ctx, func(m map[string]*Series) (bool, error) {
+		delete(m, id)
+		return true, nil
+	})
+}
+
+func (fss *FileSeriesStore) Reserve(ctx context.Context, id, fromTaskID, holder string, expires time.Time) error {
+	return fss.file.locked(ctx, func(m map[string]*Series) (bool, error) {
+		series := m[id]
+		if series == nil {
+			return false, fmt.Errorf("%s (%w)", id, ErrSeriesNotFound)
+		}
+
+		if series.TaskID != fromTaskID {
+			return false, fmt.Errorf("%s (%w)", id, ErrSeriesConflict)
+		}
+
+		if series.ReservedBy != "" && series.ReservedBy != holder && time.Now().Before(series.ReservedUntil) {
+			return false, fmt.Errorf("%s reserved by %s (%w)", id, series.ReservedBy, ErrSeriesConflict)
+		}
+
+		series.ReservedBy = holder
+		series.ReservedUntil = expires
+
+		return true, nil
+	})
+}
+
+func (fss *FileSeriesStore) Advance(ctx context.Context, id, fromTaskID, toTaskID string) error {
+	return fss.file.locked(ctx, func(m map[string]*Series) (bool, error) {
+		series := m[id]
+		if series == nil {
+			return false, fmt.Errorf("%s (%w)", id, ErrSeriesNotFound)
+		}
+
+		if series.TaskID == toTaskID {
+			// Another reconciler adopted our occurrence and advanced first
+			return false, nil
+		}
+
+		if series.TaskID != fromTaskID {
+			return false, fmt.Errorf("%s (%w)", id, ErrSeriesConflict)
+		}
+
+		series.TaskID = toTaskID
+		series.ReservedBy = ""
+		series.ReservedUntil = time.Time{}
+
+		return true, nil
+	})
+}
+
+// FileRelationStore keeps relations in a JSON file, with the same locking
+// as FileSeriesStore.
+type FileRelationStore struct {
+	file *jsonFile[*Relation]
+}
+
+func NewFileRelationStore(path string) *FileRelationStore {
+	return &FileRelationStore{
+		file: &jsonFile[*Relation]{
+			path: path,
+		},
+	}
+}
+
+func (frs *FileRelationStore) List(ctx context.Context) ([]*Relation, error) {
+	ret := []*Relation{}
+
+	err := frs.file.locked(ctx, func(m map[string]*Relation) (bool, error) {
+		for _, rel := range m {
+			ret = append(ret, rel)
+		}
+
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].key() < ret[j].key() })
+
+	return ret, nil
+}
+
+func (frs *FileRelationStore) Add(ctx context.Context, rel *Relation, check func([]*Relation) error) error {
+	return frs.file.locked(ctx, func(m map[string]*Relation) (bool, error) {
+		rels := []*Relation{}
+
+		for _, existing := range m {
+			rels = append(rels, existing)
+		}
+
+		err := check(rels)
+		if err != nil {
+			return false, err
+		}
+
+		m[rel.key()] = rel
+
+		return true, nil
+	})
+}
+
+func (frs *FileRelationStore) Remove(ctx context.Context, rel *Relation) error {
+	return frs.file.locked(ctx, func(m map[string]*Relation) (bool, error) {
+		delete(m, rel.key())
+		return true, nil
+	})
+}
+
+// locked runs cb with the current contents under both the in-process and
+// file locks, writing the map back if cb returns true
+func (jf *jsonFile[V]) locked(ctx context.Context, cb func(map[string]V) (bool, error)) error {
+	jf.mu.Lock()
+	defer jf.mu.Unlock()
+
+	unlock, err := jf.lock(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	m := map[string]V{}
+
+	js, err := os.ReadFile(jf.path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+
+	case err != nil:
+		return err
+
+	default:
+		err = json.Unmarshal(js, &m)
+		if err != nil {
+			return err
+		}
+	}
+
+	write, err := cb(m)
+	if err != nil || !write {
+		return err
+	}
+
+	js, err = json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp := jf.path + ".tmp"
+
+	err = os.WriteFile(tmp, js, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, jf.path)
+}
+
+func (jf *jsonFile[V]) lock(ctx context.Context) (func(), error) {
+	lockPath := jf.path + ".lock"
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		// Holder died without cleaning up
+		fi, err := os.Stat(lockPath)
+		if err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+
+		t := time.NewTimer(lockRetry)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
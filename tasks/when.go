@@ -0,0 +1,278 @@
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTime = fmt.Errorf("invalid time")
+
+var absoluteLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var weekdays = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdays[name] = d
+		weekdays[name[:3]] = d
+	}
+}
+
+// ParseWhen resolves human time expressions relative to now, in now's
+// location, so calendar steps like "tomorrow" respect DST. Accepted forms
+// include "now", "in 3 days", "+2h", "tomorrow 9am", "9am tomorrow",
+// "next monday", "fri at 17:30", "tonight", "noon" and absolute dates such as
+// "2026-01-02 15:04". A bare weekday (with or without "next") is the next
+// such day after today. A bare time of day, or "tonight", already passed
+// today means tomorrow. A day without a time means the start of that day.
+func ParseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	loc := now.Location()
+
+	// Before lowercasing, since layouts like RFC3339 need "T" and "Z"
+	for _, layout := range absoluteLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	s = strings.ToLower(s)
+
+	if s == "now" {
+		return now, nil
+	}
+
+	if t, ok := parseOffset(s, now); ok {
+		return t, nil
+	}
+
+	words := []string{}
+
+	for _, w := range strings.Fields(s) {
+		if w != "at" && w != "on" {
+			words = append(words, w)
+		}
+	}
+
+	var (
+		day          *time.Time
+		clock        *time.Duration
+		defaultClock bool
+		rollForward  bool
+	)
+
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+
+		if w == "next" && i+1 < len(words) {
+			i++
+			w = words[i]
+
+			if w == "week" {
+				d := nextWeekday(now, time.Monday)
+				day = &d
+
+				continue
+			}
+		}
+
+		if d, ok := parseDay(w, now); ok && day == nil {
+			day = &d
+
+			if w == "tonight" {
+				rollForward = true
+
+				if clock == nil {
+					c := 18 * time.Hour
+					clock = &c
+					defaultClock = true
+				}
+			}
+
+			continue
+		}
+
+		// "9 am" as two words
+		if i+1 < len(words) && (words[i+1] == "am" || words[i+1] == "pm") {
+			w += words[i+1]
+			i++
+		}
+
+		if c, ok := parseClock(w); ok && (clock == nil || defaultClock) {
+			clock = &c
+			defaultClock = false
+
+			continue
+		}
+
+		return time.Time{}, fmt.Errorf("%s (%w)", s, ErrInvalidTime)
+	}
+
+	if day == nil && clock == nil {
+		return time.Time{}, fmt.Errorf("%s (%w)", s, ErrInvalidTime)
+	}
+
+	if day == nil || rollForward {
+		t := atClock(now, *clock)
+		if !t.After(now) {
+			t = atClock(now.AddDate(0, 0, 1), *clock)
+		}
+
+		return t, nil
+	}
+
+	if clock == nil {
+		return atClock(*day, 0), nil
+	}
+
+	return atClock(*day, *clock), nil
+}
+
+func parseOffset(s string, now time.Time) (time.Time, bool) {
+	switch {
+	case strings.HasPrefix(s, "in "):
+		s = strings.TrimPrefix(s, "in ")
+	case strings.HasPrefix(s, "+"):
+		s = strings.TrimPrefix(s, "+")
+	default:
+		return time.Time{}, false
+	}
+
+	s = strings.TrimSpace(s)
+
+	d, err := time.ParseDuration(strings.ReplaceAll(s, " ", ""))
+	if err == nil {
+		return now.Add(d), true
+	}
+
+	num, unit, found := strings.Cut(s, " ")
+	if !found {
+		// "3d", "2w"
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return time.Time{}, false
+		}
+
+		num, unit = s[:i], s[i:]
+	}
+
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch strings.TrimSuffix(strings.TrimSpace(unit), "s") {
+	case "m", "min", "minute":
+		return now.Add(time.Duration(n) * time.Minute), true
+	case "h", "hr", "hour":
+		return now.Add(time.Duration(n) * time.Hour), true
+	case "d", "day":
+		return now.AddDate(0, 0, n), true
+	case "w", "week":
+		return now.AddDate(0, 0, 7*n), true
+	case "month":
+		return now.AddDate(0, n, 0), true
+	case "y", "year":
+		return now.AddDate(n, 0, 0), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func parseDay(w string, now time.Time) (time.Time, bool) {
+	switch w {
+	case "today", "tonight":
+		return now, true
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), true
+	case "yesterday":
+		return now.AddDate(0, 0, -1), true
+	}
+
+	if wd, ok := weekdays[w]; ok {
+		return nextWeekday(now, wd), true
+	}
+
+	return time.Time{}, false
+}
+
+func parseClock(w string) (time.Duration, bool) {
+	switch w {
+	case "midnight":
+		return 0, true
+	case "noon":
+		return 12 * time.Hour, true
+	case "morning":
+		return 9 * time.Hour, true
+	case "evening":
+		return 18 * time.Hour, true
+	}
+
+	offset := -1
+
+	switch {
+	case strings.HasSuffix(w, "am"):
+		offset = 0
+		w = strings.TrimSuffix(w, "am")
+	case strings.HasSuffix(w, "pm"):
+		offset = 12
+		w = strings.TrimSuffix(w, "pm")
+	}
+
+	hs, ms, hasMinutes := strings.Cut(w, ":")
+
+	if !hasMinutes && offset == -1 {
+		// A bare number is ambiguous
+		return 0, false
+	}
+
+	h, err := strconv.Atoi(hs)
+	if err != nil {
+		return 0, false
+	}
+
+	m := 0
+
+	if hasMinutes {
+		m, err = strconv.Atoi(ms)
+		if err != nil || m < 0 || m > 59 {
+			return 0, false
+		}
+	}
+
+	if offset == -1 {
+		if h < 0 || h > 23 {
+			return 0, false
+		}
+	} else {
+		if h < 1 || h > 12 {
+			return 0, false
+		}
+
+		h = h%12 + offset
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, true
+}
+
+func nextWeekday(now time.Time, wd time.Weekday) time.Time {
+	days := (int(wd) - int(now.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+
+	return now.AddDate(0, 0, days)
+}
+
+func atClock(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(clock.Hours()), int(clock.Minutes())%60, 0, 0, day.Location())
+}
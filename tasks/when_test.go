@@ -0,0 +1,74 @@
+package tasks_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo/tasks"
+)
+
+func TestParseWhen(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("test", -5*60*60)
+
+	// A Wednesday
+	now := time.Date(2026, 1, 7, 10, 30, 0, 0, loc)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-01-02T15:04:05Z", time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2026-01-02T15:04:05-05:00", time.Date(2026, 1, 2, 15, 4, 5, 0, loc)},
+		{" 2026-01-02T15:04 ", time.Date(2026, 1, 2, 15, 4, 0, 0, loc)},
+		{"2026-01-02 15:04", time.Date(2026, 1, 2, 15, 4, 0, 0, loc)},
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, loc)},
+		{"Now", now},
+		{"in 3 days", now.AddDate(0, 0, 3)},
+		{"+2h", now.Add(2 * time.Hour)},
+		{"Tomorrow 9AM", time.Date(2026, 1, 8, 9, 0, 0, 0, loc)},
+		{"9am", time.Date(2026, 1, 8, 9, 0, 0, 0, loc)},
+		{"next monday", time.Date(2026, 1, 12, 0, 0, 0, 0, loc)},
+		{"fri at 17:30", time.Date(2026, 1, 9, 17, 30, 0, 0, loc)},
+		{"tonight", time.Date(2026, 1, 7, 18, 0, 0, 0, loc)},
+	}
+
+	for _, test := range tests {
+		got, err := tasks.ParseWhen(test.in, now)
+		if err != nil {
+			t.Errorf("ParseWhen(%q): %s", test.in, err)
+			continue
+		}
+
+		if !got.Equal(test.want) {
+			t.Errorf("ParseWhen(%q) = %s, want %s", test.in, got, test.want)
+		}
+	}
+
+	evening := time.Date(2026, 1, 7, 20, 0, 0, 0, loc)
+
+	for in, want := range map[string]time.Time{
+		"tonight":      time.Date(2026, 1, 8, 18, 0, 0, 0, loc),
+		"tonight 11pm": time.Date(2026, 1, 7, 23, 0, 0, 0, loc),
+		"7pm tonight":  time.Date(2026, 1, 8, 19, 0, 0, 0, loc),
+		"9pm":          time.Date(2026, 1, 7, 21, 0, 0, 0, loc),
+		"8pm":          time.Date(2026, 1, 8, 20, 0, 0, 0, loc),
+	} {
+		got, err := tasks.ParseWhen(in, evening)
+		if err != nil {
+			t.Errorf("ParseWhen(%q) at 20:00: %s", in, err)
+			continue
+		}
+
+		if !got.Equal(want) {
+			t.Errorf("ParseWhen(%q) at 20:00 = %s, want %s", in, got, want)
+		}
+	}
+
+	_, err := tasks.ParseWhen("someday", now)
+	if !errors.Is(err, tasks.ErrInvalidTime) {
+		t.Errorf("ParseWhen(someday) = %v, want ErrInvalidTime", err)
+	}
+}
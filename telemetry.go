@@ -0,0 +1,245 @@
+package gosolo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slices"
+)
+
+const instrumentationName = "github.com/tasksolo/gosolo"
+
+type TelemetryConfig struct {
+	// Defaults to otel.GetTracerProvider()
+	TracerProvider trace.TracerProvider
+
+	// Defaults to otel.GetMeterProvider()
+	MeterProvider metric.MeterProvider
+
+	// Defaults to otel.GetTextMapPropagator()
+	Propagator propagation.TextMapPropagator
+}
+
+type telemetry struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	duration     metric.Float64Histogram
+	errors       metric.Int64Counter
+	conditional  metric.Int64Counter
+	reconnects   metric.Int64Counter
+	streamEvents metric.Int64Counter
+}
+
+// SetTelemetry enables OpenTelemetry spans, metrics and trace context
+// propagation for all requests made through this Client. A nil cfg disables
+// it. Instrument creation errors go to otel.Handle, as is OTel convention.
+//
+// Spans are per HTTP request, named for the generic operation that sent it
+// (e.g. gosolo.ListName). An operation that sends several requests, like
+// DiffUpdateName falling back to a merge patch, gets one span each. A stream
+// span ends once the response headers arrive, and each reconnect gets its
+// own span with gosolo.attempt; use the stream metrics to follow a stream
+// after that.
+func (c *Client) SetTelemetry(cfg *TelemetryConfig) *Client {
+	c.telemetry = nil
+
+	if cfg != nil {
+		t, err := newTelemetry(cfg)
+		if err != nil {
+			otel.Handle(err)
+		} else {
+			c.telemetry = t
+		}
+	}
+
+	c.updateTransport()
+
+	return c
+}
+
+func newTelemetry(cfg *TelemetryConfig) (*telemetry, error) {
+	tp := cfg.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	mp := cfg.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	prop := cfg.Propagator
+	if prop == nil {
+		prop = otel.GetTextMapPropagator()
+	}
+
+	meter := mp.Meter(instrumentationName)
+
+	t := &telemetry{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: prop,
+	}
+
+	var err error
+
+	t.duration, err = meter.Float64Histogram(
+		"gosolo.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of requests to the Solø API"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.errors, err = meter.Int64Counter(
+		"gosolo.client.request.errors",
+		metric.WithDescription("Failed requests to the Solø API, by status code"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.conditional, err = meter.Int64Counter(
+		"gosolo.client.request.conditional",
+		metric.WithDescription("Requests sent with If-None-Match, by ETag hit (304) or miss"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.reconnects, err = meter.Int64Counter(
+		"gosolo.client.stream.reconnects",
+		metric.WithDescription("Stream reconnect attempts"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.streamEvents, err = meter.Int64Counter(
+		"gosolo.client.stream.events",
+		metric.WithDescription("Stream events received, by event type"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (c *Client) telemetryWrap(next http.RoundTripper) http.RoundTripper {
+	t := c.telemetry
+
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resource, id := c.resourcePath(req.URL)
+
+		op := operationFromContext(req.Context())
+
+		attrs := []attribute.KeyValue{
+			attribute.String("gosolo.operation", op),
+			attribute.String("gosolo.resource", resource),
+			attribute.String("http.method", req.Method),
+		}
+
+		spanAttrs := slices.Clone(attrs)
+
+		if id != "" {
+			spanAttrs = append(spanAttrs, attribute.String("gosolo.id", id))
+		}
+
+		if attempt, ok := req.Context().Value(attemptKey).(int); ok {
+			spanAttrs = append(spanAttrs, attribute.Int("gosolo.attempt", attempt))
+		}
+
+		ctx, span := t.tracer.Start(
+			req.Context(),
+			"gosolo."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(spanAttrs...),
+		)
+		defer span.End()
+
+		req = req.Clone(ctx)
+		t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		start := time.Now()
+
+		resp, err := next.RoundTrip(req)
+
+		duration := time.Since(start).Seconds()
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+
+			t.duration.Record(ctx, duration, metric.WithAttributes(attrs...))
+			t.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("gosolo.error_code", "transport"))...))
+
+			return resp, err
+		}
+
+		span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+		t.duration.Record(ctx, duration, metric.WithAttributes(append(slices.Clone(attrs), attribute.Int("http.status_code", resp.StatusCode))...))
+
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+			t.errors.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("gosolo.error_code", strconv.Itoa(resp.StatusCode)))...))
+		}
+
+		if req.Header.Get("If-None-Match") != "" {
+			t.conditional.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("gosolo.resource", resource),
+				attribute.Bool("gosolo.etag_hit", resp.StatusCode == http.StatusNotModified),
+			))
+		}
+
+		return resp, err
+	})
+}
+
+func (c *Client) recordReconnect(ctx context.Context, name string) {
+	if c == nil || c.telemetry == nil {
+		return
+	}
+
+	c.telemetry.reconnects.Add(ctx, 1, metric.WithAttributes(attribute.String("gosolo.resource", name)))
+}
+
+func (c *Client) recordStreamEvent(ctx context.Context, name, eventType string) {
+	if c == nil || c.telemetry == nil {
+		return
+	}
+
+	c.telemetry.streamEvents.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("gosolo.resource", name),
+		attribute.String("gosolo.event", eventType),
+	))
+}
+
+// withOperation keeps any outer operation, so e.g. FindName's request is
+// reported as FindName rather than the ListName it calls
+func withOperation(ctx context.Context, op string) context.Context {
+	if _, ok := ctx.Value(operationKey).(string); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, operationKey, op)
+}
+
+func operationFromContext(ctx context.Context) string {
+	op, ok := ctx.Value(operationKey).(string)
+	if !ok {
+		return "request"
+	}
+
+	return op
+}
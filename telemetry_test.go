@@ -0,0 +1,123 @@
+package gosolo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTelemetry(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Traceparent") == "" {
+			t.Errorf("missing traceparent on %s", r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/v1/task/abc":
+			_, _ = w.Write([]byte(`{"id":"abc","etag":"etag:1","name":"A"}`))
+
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"messages":["boom"]}`))
+		}
+	}))
+	defer srv.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+
+	c := gosolo.NewClientDirect(srv.URL).SetTelemetry(&gosolo.TelemetryConfig{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		Propagator:     propagation.TraceContext{},
+	})
+
+	ctx := context.Background()
+
+	_, err := c.GetTask(ctx, "abc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.GetTask(ctx, "broken", nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("got %d spans, want 2", len(ended))
+	}
+
+	for _, span := range ended {
+		if span.Name() != "gosolo.GetName" {
+			t.Errorf("span name %q", span.Name())
+		}
+	}
+
+	attrs := attribute.NewSet(ended[0].Attributes()...)
+
+	if val, _ := attrs.Value("gosolo.id"); val.AsString() != "abc" {
+		t.Errorf("gosolo.id = %q", val.AsString())
+	}
+
+	if val, _ := attrs.Value("http.status_code"); val.AsInt64() != 200 {
+		t.Errorf("http.status_code = %d", val.AsInt64())
+	}
+
+	if ended[1].Status().Code != codes.Error {
+		t.Errorf("failed span status %v", ended[1].Status())
+	}
+
+	rm := metricdata.ResourceMetrics{}
+
+	err = reader.Collect(ctx, &rm)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]metricdata.Aggregation{}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = m.Data
+		}
+	}
+
+	hist, ok := got["gosolo.client.request.duration"].(metricdata.Histogram[float64])
+	if !ok {
+		t.Fatalf("duration histogram missing: %#v", got)
+	}
+
+	count := uint64(0)
+	for _, dp := range hist.DataPoints {
+		count += dp.Count
+	}
+
+	if count != 2 {
+		t.Errorf("duration count %d, want 2", count)
+	}
+
+	errs, ok := got["gosolo.client.request.errors"].(metricdata.Sum[int64])
+	if !ok || len(errs.DataPoints) != 1 {
+		t.Fatalf("errors counter: %#v", got["gosolo.client.request.errors"])
+	}
+
+	if val, _ := errs.DataPoints[0].Attributes.Value("gosolo.error_code"); val.AsString() != "500" {
+		t.Errorf("gosolo.error_code = %q", val.AsString())
+	}
+}
@@ -0,0 +1,135 @@
+package todotxt
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/tasksolo/gosolo"
+)
+
+type ImportOpts struct {
+	Options
+
+	// Requests in flight at once; defaults to 8
+	Concurrency int
+}
+
+type ImportResult struct {
+	Created []*gosolo.Task
+	Updated []*gosolo.Task
+}
+
+// Exporter writes the task list in todo.txt format, skipping the write when
+// the list's ETag shows nothing changed since the previous Export.
+type Exporter struct {
+	client *gosolo.Client
+	opts   *Options
+	prev   []*gosolo.Task
+}
+
+const defaultConcurrency = 8
+
+var lineFields = []string{"name", "complete", "after"}
+
+// Import creates tasks for lines without an id: tag and updates tasks for
+// lines with one, running up to Concurrency requests at a time. On failure
+// it returns the first error along with every line that did succeed.
+func Import(ctx context.Context, c *gosolo.Client, r io.Reader, opts *ImportOpts) (*ImportResult, error) {
+	if opts == nil {
+		opts = &ImportOpts{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	list, err := Read(r, &opts.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*gosolo.Task, len(list))
+	errs := make([]error, len(list))
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for i, task := range list {
+		i, task := i, task
+
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if task.ID == "" {
+				results[i], errs[i] = c.CreateTask(ctx, task)
+			} else {
+				// Every field a line carries, so e.g. dropping "x " clears Complete
+				results[i], errs[i] = c.UpdateTask(ctx, task.ID, task, &gosolo.UpdateOpts[gosolo.Task]{
+					Fields: lineFields,
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	ret := &ImportResult{}
+
+	var firstErr error
+
+	for i, task := range list {
+		if errs[i] != nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+
+			continue
+		}
+
+		if task.ID == "" {
+			ret.Created = append(ret.Created, results[i])
+		} else {
+			ret.Updated = append(ret.Updated, results[i])
+		}
+	}
+
+	return ret, firstErr
+}
+
+func NewExporter(c *gosolo.Client, opts *Options) *Exporter {
+	return &Exporter{
+		client: c,
+		opts:   opts,
+	}
+}
+
+// Export returns false without writing if the list is unchanged.
+func (e *Exporter) Export(ctx context.Context, w io.Writer) (bool, error) {
+	list, err := e.client.ListTask(ctx, &gosolo.ListOpts[gosolo.Task]{
+		Prev: e.prev,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(e.prev) > 0 && len(list) > 0 && list[0].ListETag == e.prev[0].ListETag {
+		return false, nil
+	}
+
+	err = Write(w, list, e.opts)
+	if err != nil {
+		return false, err
+	}
+
+	e.prev = list
+
+	return true, nil
+}
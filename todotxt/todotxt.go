@@ -0,0 +1,171 @@
+// Package todotxt converts between todo.txt lines and Solø tasks.
+//
+// A leading "x " marks the task Complete, a "t:" threshold date becomes
+// After, and an "id:" tag carries the task ID so re-importing an export
+// updates rather than duplicates. Everything else on the line, including
+// priority, dates, +projects and @contexts, is kept in Name with single
+// spaces between words. Parse(Format(task)) reproduces task except when its
+// Name has runs of spaces, starts with "x ", or has words that read as t: or
+// id: tags; todo.txt has no way to escape those.
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+)
+
+type Options struct {
+	// Location for t: dates; defaults to time.Local
+	Location *time.Location
+
+	// Write id: tags on export
+	IncludeIDs bool
+}
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02T15:04:05Z07:00"
+)
+
+var ErrInvalidLine = fmt.Errorf("invalid todo.txt line")
+
+func Parse(line string, opts *Options) (*gosolo.Task, error) {
+	opts = opts.withDefaults()
+
+	task := &gosolo.Task{}
+
+	if strings.HasPrefix(line, "x ") {
+		task.Complete = true
+		line = strings.TrimPrefix(line, "x ")
+	}
+
+	words := []string{}
+
+	for _, word := range strings.Fields(line) {
+		key, val, found := strings.Cut(word, ":")
+
+		switch {
+		case found && key == "t" && val != "":
+			after, err := parseDate(val, opts.Location)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s (%w)", word, err, ErrInvalidLine) //nolint:errorlint
+			}
+
+			task.After = after
+
+		case found && key == "id" && val != "":
+			task.ID = val
+
+		default:
+			words = append(words, word)
+		}
+	}
+
+	task.Name = strings.Join(words, " ")
+
+	if task.Name == "" {
+		return nil, fmt.Errorf("empty task (%w)", ErrInvalidLine)
+	}
+
+	return task, nil
+}
+
+func Format(task *gosolo.Task, opts *Options) string {
+	opts = opts.withDefaults()
+
+	parts := []string{}
+
+	if task.Complete {
+		parts = append(parts, "x")
+	}
+
+	parts = append(parts, task.Name)
+
+	if !task.After.IsZero() {
+		parts = append(parts, "t:"+formatDate(task.After, opts.Location))
+	}
+
+	if opts.IncludeIDs && task.ID != "" {
+		parts = append(parts, "id:"+task.ID)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Read parses every non-blank line of r
+func Read(r io.Reader, opts *Options) ([]*gosolo.Task, error) {
+	ret := []*gosolo.Task{}
+	scan := bufio.NewScanner(r)
+
+	for lineNum := 1; scan.Scan(); lineNum++ {
+		if strings.TrimSpace(scan.Text()) == "" {
+			continue
+		}
+
+		task, err := Parse(scan.Text(), opts)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		ret = append(ret, task)
+	}
+
+	err := scan.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func Write(w io.Writer, list []*gosolo.Task, opts *Options) error {
+	bw := bufio.NewWriter(w)
+
+	for _, task := range list {
+		_, err := fmt.Fprintln(bw, Format(task, opts))
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (opts *Options) withDefaults() *Options {
+	ret := &Options{}
+
+	if opts != nil {
+		*ret = *opts
+	}
+
+	if ret.Location == nil {
+		ret.Location = time.Local
+	}
+
+	return ret
+}
+
+// Thresholds are dates; a time of day only survives as an RFC 3339 value
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(dateLayout, s, loc)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.Parse(dateTimeLayout, s)
+}
+
+func formatDate(t time.Time, loc *time.Location) string {
+	t = t.In(loc)
+
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format(dateLayout)
+	}
+
+	return t.Format(dateTimeLayout)
+}
@@ -0,0 +1,85 @@
+package todotxt_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+	"github.com/tasksolo/gosolo/todotxt"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	opts := &todotxt.Options{Location: time.UTC, IncludeIDs: true}
+
+	lines := []string{
+		"(A) 2026-01-02 call mom +family @phone",
+		"x water plants t:2026-01-03 id:abc",
+		"pay rent t:2026-01-03T09:30:00Z",
+	}
+
+	for _, line := range lines {
+		task, err := todotxt.Parse(line, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := todotxt.Format(task, opts)
+		if got != line {
+			t.Errorf("Format(Parse(%q)) = %q", line, got)
+		}
+	}
+}
+
+func TestImportClearsComplete(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("task", map[string]any{"id": "abc", "name": "water plants", "complete": true})
+
+	c := gosolo.NewClientDirect(srv.URL)
+
+	res, err := todotxt.Import(context.Background(), c, strings.NewReader("water plants today id:abc\nnew task\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Created) != 1 || len(res.Updated) != 1 {
+		t.Fatalf("created %d, updated %d", len(res.Created), len(res.Updated))
+	}
+
+	obj := srv.Get("task", "abc")
+	if obj["complete"] != false || obj["name"] != "water plants today" {
+		t.Errorf("after import: %v", obj)
+	}
+}
+
+func TestImportKeepsSuccessesOnError(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	c := gosolo.NewClientDirect(srv.URL)
+
+	res, err := todotxt.Import(context.Background(), c, strings.NewReader("gone id:missing\nfirst\nsecond\n"), &todotxt.ImportOpts{
+		Concurrency: 1,
+	})
+	if err == nil {
+		t.Fatal("import of a missing id succeeded")
+	}
+
+	if res == nil || len(res.Created) != 2 || len(res.Updated) != 0 {
+		t.Fatalf("result: %+v", res)
+	}
+
+	if srv.Count("POST", "task") != 2 {
+		t.Errorf("%d creates", srv.Count("POST", "task"))
+	}
+}
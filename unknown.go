@@ -0,0 +1,281 @@
+package gosolo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Unknown holds the JSON fields of an object that its Go type doesn't
+// declare, so that writing the object back (e.g. with ReplaceName) doesn't
+// drop fields added by a newer server.
+type Unknown map[string]json.RawMessage
+
+var knownFieldsCache sync.Map // reflect.Type -> *fieldSet
+
+// UnmarshalWithUnknown decodes data into obj (which must not itself
+// implement json.Unmarshaler) and stores fields obj doesn't declare in
+// unknown. data is decoded once; finding unknown fields only scans its
+// top-level keys.
+func UnmarshalWithUnknown(data []byte, obj any, unknown *Unknown) error {
+	err := json.Unmarshal(data, obj)
+	if err != nil {
+		return err
+	}
+
+	known := knownFields(reflect.TypeOf(obj).Elem())
+	*unknown = nil
+
+	// data is valid JSON, or Unmarshal would have failed
+	return scanObject(data, func(key, val []byte) error {
+		if known.has(key) {
+			return nil
+		}
+
+		name := ""
+
+		if bytes.IndexByte(key, '\\') == -1 {
+			name = string(key[1 : len(key)-1])
+		} else {
+			err := json.Unmarshal(key, &name)
+			if err != nil {
+				return err
+			}
+		}
+
+		if *unknown == nil {
+			*unknown = Unknown{}
+		}
+
+		(*unknown)[name] = append(json.RawMessage{}, val...)
+
+		return nil
+	})
+}
+
+// MarshalWithUnknown encodes obj (which must not itself implement
+// json.Marshaler) followed by the fields in unknown.
+func MarshalWithUnknown(obj any, unknown Unknown) ([]byte, error) {
+	js, err := json.Marshal(obj)
+	if err != nil || len(unknown) == 0 {
+		return js, err
+	}
+
+	keys := make([]string, 0, len(unknown))
+
+	for key := range unknown {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	buf := bytes.NewBuffer(js[:len(js)-1])
+	first := bytes.Equal(js, []byte("{}"))
+
+	for _, key := range keys {
+		if !first {
+			buf.WriteByte(',')
+		}
+
+		first = false
+
+		keyJS, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(keyJS)
+		buf.WriteByte(':')
+		buf.Write(unknown[key])
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// Clone returns a copy that shares no map with u. The values themselves
+// are never modified in place, so they are shared.
+func (u Unknown) Clone() Unknown {
+	if u == nil {
+		return nil
+	}
+
+	ret := make(Unknown, len(u))
+
+	for key, val := range u {
+		ret[key] = val
+	}
+
+	return ret
+}
+
+type fieldSet struct {
+	exact map[string]bool
+
+	// encoding/json matches names case-insensitively
+	folded map[string]bool
+}
+
+// has reports whether quoted JSON key (as it appears in the input) names a
+// field in fs
+func (fs *fieldSet) has(key []byte) bool {
+	if fs.exact[string(key[1:len(key)-1])] {
+		return true
+	}
+
+	name := ""
+
+	err := json.Unmarshal(key, &name)
+	if err != nil {
+		return false
+	}
+
+	return fs.folded[strings.ToLower(name)]
+}
+
+// knownFields returns the JSON names t declares, including those promoted
+// from embedded structs.
+func knownFields(t reflect.Type) *fieldSet {
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(*fieldSet)
+	}
+
+	ret := &fieldSet{
+		exact:  map[string]bool{},
+		folded: map[string]bool{},
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			sub := knownFields(field.Type)
+
+			for key := range sub.exact {
+				ret.exact[key] = true
+				ret.folded[strings.ToLower(key)] = true
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		ret.exact[name] = true
+		ret.folded[strings.ToLower(name)] = true
+	}
+
+	knownFieldsCache.Store(t, ret)
+
+	return ret
+}
+
+// scanObject calls fn with each quoted key and raw value at the top level
+// of data, which must be valid JSON. Anything but an object is skipped.
+func scanObject(data []byte, fn func(key, val []byte) error) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return nil
+	}
+
+	i = skipSpace(data, i+1)
+
+	for i < len(data) && data[i] != '}' {
+		keyStart := i
+		i = skipString(data, i)
+		key := data[keyStart:i]
+
+		// Past the colon
+		i = skipSpace(data, skipSpace(data, i)+1)
+
+		valStart := i
+		i = skipValue(data, i)
+
+		err := fn(key, data[valStart:i])
+		if err != nil {
+			return err
+		}
+
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+
+	return nil
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+
+	return i
+}
+
+// skipString returns the index after the string starting at data[i]
+func skipString(data []byte, i int) int {
+	for i++; i < len(data) && data[i] != '"'; i++ {
+		if data[i] == '\\' {
+			i++
+		}
+	}
+
+	return i + 1
+}
+
+// skipValue returns the index after the value starting at data[i]
+func skipValue(data []byte, i int) int {
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+
+	case '{', '[':
+		depth := 0
+
+		for i < len(data) {
+			switch data[i] {
+			case '"':
+				i = skipString(data, i)
+				continue
+
+			case '{', '[':
+				depth++
+
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+
+			i++
+		}
+
+		return i
+
+	default:
+		for i < len(data) && bytes.IndexByte([]byte(",}] \t\r\n"), data[i]) == -1 {
+			i++
+		}
+
+		return i
+	}
+}
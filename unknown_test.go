@@ -0,0 +1,127 @@
+package gosolo_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/tasksolo/gosolo"
+	"github.com/tasksolo/gosolo/internal/fakeapi"
+)
+
+func TestUnknownRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := `{"id":"t1","name":"A","priority":3,"labels":["x","y"],"nested":{"a":null}}`
+
+	task := &gosolo.Task{}
+
+	err := json.Unmarshal([]byte(in), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.ID != "t1" || task.Name != "A" {
+		t.Errorf("known fields: %+v", task)
+	}
+
+	if len(task.Unknown) != 3 || string(task.Unknown["labels"]) != `["x","y"]` {
+		t.Errorf("unknown fields: %v", task.Unknown)
+	}
+
+	out, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got map[string]any
+
+	_ = json.Unmarshal([]byte(in), &want)
+	_ = json.Unmarshal(out, &got)
+
+	for key, val := range want {
+		gotJS, _ := json.Marshal(got[key])
+		wantJS, _ := json.Marshal(val)
+
+		if string(gotJS) != string(wantJS) {
+			t.Errorf("%s: got %s, want %s", key, gotJS, wantJS)
+		}
+	}
+
+	// Decoding again without unknown fields clears them
+	err = json.Unmarshal([]byte(`{"id":"t2","complete":true}`), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.Unknown != nil || task.ID != "t2" || !task.Complete || task.Name != "A" {
+		t.Errorf("second decode: %+v", task)
+	}
+
+	// Case-insensitive and escaped names, as encoding/json matches them
+	err = json.Unmarshal([]byte(`{"NAME":"C", "\u0063omplete": false, "a\"b" : [1, {"c": "]"}]}`), task)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if task.Name != "C" || task.Complete || len(task.Unknown) != 1 || string(task.Unknown[`a"b`]) != `[1, {"c": "]"}]` {
+		t.Errorf("folded decode: %+v", task)
+	}
+
+	err = json.Unmarshal([]byte(`{"name":7,"other":1}`), task)
+	if err == nil {
+		t.Error("expected type error")
+	}
+}
+
+func TestUnknownSurvivesReplace(t *testing.T) {
+	t.Parallel()
+
+	srv := fakeapi.New()
+	defer srv.Close()
+
+	srv.Put("task", map[string]any{"id": "t1", "name": "A", "priority": 3.0})
+
+	ctx := context.Background()
+	c := gosolo.NewClientDirect(srv.URL)
+
+	task, err := c.GetTask(ctx, "t1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task.Name = "B"
+
+	_, err = c.ReplaceTask(ctx, "t1", task, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := srv.Get("task", "t1")
+	if stored["name"] != "B" || stored["priority"] != 3.0 {
+		t.Errorf("stored %v", stored)
+	}
+}
+
+func BenchmarkUnmarshalTask(b *testing.B) {
+	for _, bench := range []struct {
+		name string
+		js   string
+	}{
+		{name: "known", js: `{"id":"t1","etag":"e1","generation":2,"userID":"u1","name":"A","complete":true,"after":"2026-01-01T00:00:00Z"}`},
+		{name: "unknown", js: `{"id":"t1","etag":"e1","generation":2,"userID":"u1","name":"A","complete":true,"after":"2026-01-01T00:00:00Z","priority":3}`},
+	} {
+		js := []byte(bench.js)
+
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				task := &gosolo.Task{}
+
+				err := json.Unmarshal(js, task)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}